@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/api"
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// runImport implements the import subcommand, printing the report as JSON
+func runImport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	vault := flags.String("vault", "", "vault to import from, by the name shown in /health (default: the default vault)")
+	folder := flags.String("folder", "", "vault folder to import, relative to the vault root (default: whole vault)")
+	classify := flags.Bool("classify", false, "let the LLM pick each note's title and category")
+	dryRun := flags.Bool("dry-run", false, "report what would be imported without changing anything")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s import [-vault name] [-folder path] [-classify] [-dry-run]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	// Keep the route table out of the report output
+	gin.SetMode(gin.ReleaseMode)
+
+	server := api.NewCommandServer()
+	report, err := server.ImportVault(context.Background(), models.ImportOptions{
+		Vault:    *vault,
+		Folder:   *folder,
+		Classify: *classify,
+		DryRun:   *dryRun,
+	})
+	server.Close()
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to print report: %v", err)
+	}
+
+	if report.Summary[models.ImportFailed] > 0 {
+		os.Exit(1)
+	}
+}
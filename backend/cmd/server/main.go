@@ -1,22 +1,42 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/kilo40/idea-forge/internal/api"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "resync":
+			runResync(os.Args[2:])
+			return
+		case "import":
+			runImport(os.Args[2:])
+			return
+		}
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	server := api.NewServer()
 
 	log.Printf("Starting Idea Forge API server on :%s", port)
-	if err := server.Run(":" + port); err != nil {
+	err := server.Run(ctx, ":"+port)
+	// Commit batched vault changes before the container stops, even after a failed start
+	server.Close()
+	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
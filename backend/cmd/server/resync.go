@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/api"
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// runResync implements the resync subcommand, printing the report as JSON
+func runResync(args []string) {
+	flags := flag.NewFlagSet("resync", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report drift without changing anything")
+	orphans := flags.String("orphans", "", "handle orphaned files: import or quarantine (default: leave them)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s resync [-dry-run] [-orphans import|quarantine]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if !models.IsValidOrphanMode(*orphans) {
+		log.Fatalf("Invalid -orphans value %q, expected import or quarantine", *orphans)
+	}
+
+	// Keep the route table out of the report output
+	gin.SetMode(gin.ReleaseMode)
+
+	server := api.NewCommandServer()
+	report, err := server.Resync(models.ResyncOptions{DryRun: *dryRun, Orphans: *orphans})
+	server.Close()
+	if err != nil {
+		log.Fatalf("Resync failed: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to print report: %v", err)
+	}
+
+	for _, action := range report.Actions {
+		if action.Result == models.ResyncFailed {
+			os.Exit(1)
+		}
+	}
+}
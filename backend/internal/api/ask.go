@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+const (
+	// askContextLimit is the number of notes retrieved as context for a question
+	askContextLimit = 6
+	// askHistoryTurns is the number of earlier messages of a session replayed with a question
+	askHistoryTurns = 6
+	// defaultAskThreshold is the semantic similarity a note needs to be retrieved for a question
+	defaultAskThreshold = 0.3
+)
+
+// retrieveNotes finds the notes most relevant to a query, combining semantic, similarity and keyword search
+func (s *Server) retrieveNotes(ctx context.Context, query string, limit int) []models.ProcessedNote {
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if s.embedder != nil {
+		matches, err := s.semanticMatches(ctx, query, limit)
+		if err != nil {
+			log.Printf("Semantic retrieval failed (continuing): %v", err)
+		}
+		// Weak semantic matches would crowd out the keyword matches below
+		for _, match := range matches {
+			if match.Score >= s.askThreshold {
+				add(match.ID)
+			}
+		}
+	}
+
+	if s.similarity != nil {
+		for _, match := range s.similarity.Query(query, limit) {
+			add(match.ID)
+		}
+	}
+
+	var notes []models.ProcessedNote
+	for _, id := range ids {
+		if len(notes) >= limit {
+			break
+		}
+		note, err := s.db.GetNote(id)
+		if err != nil || note == nil {
+			continue
+		}
+		notes = append(notes, *note)
+	}
+
+	// Fall back to keyword search when nothing else matched
+	if len(notes) == 0 {
+		keywordNotes, err := s.db.SearchNotes(query, limit)
+		if err != nil {
+			log.Printf("Keyword retrieval failed (continuing): %v", err)
+		}
+		notes = keywordNotes
+	}
+
+	return notes
+}
+
+// askNotes handles POST /api/ask
+// Relevant notes are retrieved and the LLM answers from them with citations.
+func (s *Server) askNotes(c *gin.Context) {
+	var input models.AskInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	input.Question = strings.TrimSpace(input.Question)
+	if input.Question == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Question is required",
+		})
+		return
+	}
+
+	if s.llm == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "LLM service not configured. Set ANTHROPIC_API_KEY.",
+		})
+		return
+	}
+
+	if s.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Database not available",
+		})
+		return
+	}
+
+	sessionID := input.SessionID
+	if sessionID == "" {
+		sessionID = "ask_" + uuid.New().String()[:8]
+	}
+
+	history, err := s.db.ListAskMessages(sessionID)
+	if err != nil {
+		log.Printf("Failed to load ask session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to answer question",
+		})
+		return
+	}
+
+	// Only the latest exchanges are replayed, so long sessions don't grow every prompt
+	if len(history) > askHistoryTurns {
+		history = history[len(history)-askHistoryTurns:]
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	// Follow-up questions are often vague ("and what about the backups?"), so retrieve with the previous question too
+	query := input.Question
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == "user" {
+			query = history[i].Content + "\n" + query
+			break
+		}
+	}
+
+	notes := s.retrieveNotes(ctx, query, askContextLimit)
+
+	log.Printf("Answering question with %d notes: %s", len(notes), input.Question)
+	answer, err := s.llm.AnswerQuestion(ctx, input.Question, notes, history)
+	if err != nil {
+		log.Printf("LLM answer failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to answer question",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Only cite notes that were actually provided as context
+	titles := make(map[string]string, len(notes))
+	for _, note := range notes {
+		titles[note.ID] = note.Title
+	}
+
+	citations := make([]models.Citation, 0, len(answer.Citations))
+	for _, id := range answer.Citations {
+		if title, ok := titles[id]; ok {
+			citations = append(citations, models.Citation{ID: id, Title: title})
+			delete(titles, id)
+		}
+	}
+
+	// Keep the exchange so follow-up questions have context
+	reply, _ := json.Marshal(answer)
+	now := time.Now()
+	if err := s.db.AppendAskMessages(sessionID,
+		models.ConversationTurn{Role: "user", Content: input.Question, CreatedAt: now},
+		models.ConversationTurn{Role: "assistant", Content: string(reply), CreatedAt: now},
+	); err != nil {
+		log.Printf("Failed to store ask session (continuing): %v", err)
+	}
+
+	c.JSON(http.StatusOK, models.AskResponse{
+		SessionID: sessionID,
+		Answer:    answer.Answer,
+		Citations: citations,
+	})
+}
@@ -0,0 +1,316 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/kilo40/idea-forge/internal/models"
+	"github.com/kilo40/idea-forge/internal/storage"
+)
+
+// attachmentUploadLimit is the number of files accepted in one upload request
+const attachmentUploadLimit = 10
+
+// noteAttachments looks up the attachments embedded in a note's file
+func (s *Server) noteAttachments(note *models.ProcessedNote) []models.Attachment {
+	attachments, err := s.db.ListAttachments(note.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to load attachments of %s: %v", note.ID, err)
+		return nil
+	}
+	return attachments
+}
+
+// attachmentNote loads the note of an attachment request, writing the error response if it
+// can't be used
+func (s *Server) attachmentNote(c *gin.Context) *models.ProcessedNote {
+	if s.db == nil || s.attachments == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Attachments are not available",
+		})
+		return nil
+	}
+
+	note, err := s.db.GetNote(c.Param("id"))
+	if err != nil {
+		log.Printf("Failed to get note: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve note",
+		})
+		return nil
+	}
+
+	if note == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return nil
+	}
+
+	return note
+}
+
+// uploadAttachments handles POST /api/notes/:id/attachments, a multipart upload of one or
+// more files in the "file" field. Files are sniffed and size-checked as they stream in.
+func (s *Server) uploadAttachments(c *gin.Context) {
+	note := s.attachmentNote(c)
+	if note == nil {
+		return
+	}
+
+	// The whole request may carry the file limit's worth of attachments, plus the multipart framing
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, attachmentUploadLimit*s.attachments.MaxSize()+1<<20)
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Expected a multipart/form-data upload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	created := make([]models.Attachment, 0)
+	defer func() {
+		// Embed whatever was stored, even if a later file was rejected
+		if len(created) > 0 {
+			s.syncToVault(nil, note)
+		}
+	}()
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			status := http.StatusBadRequest
+			if maxBytes := new(http.MaxBytesError); errors.As(err, &maxBytes) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			c.JSON(status, gin.H{
+				"error":   "Failed to read upload",
+				"details": err.Error(),
+			})
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+		if len(created) == attachmentUploadLimit {
+			part.Close()
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":       "Too many files",
+				"details":     fmt.Sprintf("at most %d files can be uploaded at once", attachmentUploadLimit),
+				"attachments": created,
+			})
+			return
+		}
+
+		attachment, err := s.saveAttachment(note, part)
+		part.Close()
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, storage.ErrAttachmentTooLarge):
+				status = http.StatusRequestEntityTooLarge
+			case errors.Is(err, storage.ErrAttachmentType):
+				status = http.StatusUnsupportedMediaType
+			default:
+				log.Printf("Failed to store attachment: %v", err)
+			}
+			c.JSON(status, gin.H{
+				"error":       "Failed to store " + part.FileName(),
+				"details":     err.Error(),
+				"attachments": created,
+			})
+			return
+		}
+		created = append(created, *attachment)
+	}
+
+	if len(created) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "No file uploaded",
+			"details": "send the files in the \"file\" field",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"attachments": created,
+	})
+}
+
+// saveAttachment stores an uploaded file and its record. Content is released under
+// attachmentMu, so the lock is held until the record refers to the stored file.
+func (s *Server) saveAttachment(note *models.ProcessedNote, part *multipart.Part) (*models.Attachment, error) {
+	s.attachmentMu.Lock()
+	defer s.attachmentMu.Unlock()
+
+	sum, size, mimeType, err := s.attachments.Save(part)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := models.Attachment{
+		ID:        "att_" + uuid.New().String()[:8],
+		NoteID:    note.ID,
+		Filename:  storage.AttachmentFilename(part.FileName(), mimeType),
+		SHA256:    sum,
+		MIMEType:  mimeType,
+		Size:      size,
+		CreatedAt: time.Now(),
+	}
+	if err := s.db.CreateAttachment(&attachment); err != nil {
+		s.releaseContent(note, []models.Attachment{attachment})
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// listAttachments handles GET /api/notes/:id/attachments
+func (s *Server) listAttachments(c *gin.Context) {
+	note := s.attachmentNote(c)
+	if note == nil {
+		return
+	}
+
+	attachments, err := s.db.ListAttachments(note.ID)
+	if err != nil {
+		log.Printf("Failed to list attachments: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list attachments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachments": attachments,
+		"total":       len(attachments),
+	})
+}
+
+// getAttachment handles GET /api/notes/:id/attachments/:attachmentId, downloading the file
+func (s *Server) getAttachment(c *gin.Context) {
+	note := s.attachmentNote(c)
+	if note == nil {
+		return
+	}
+	attachment := s.lookupAttachment(c, note)
+	if attachment == nil {
+		return
+	}
+
+	// Always a download: the sniffed type is trusted for the header, never for rendering inline
+	c.Header("Content-Type", attachment.MIMEType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.File(s.attachments.Path(attachment.SHA256))
+}
+
+// deleteAttachment handles DELETE /api/notes/:id/attachments/:attachmentId
+func (s *Server) deleteAttachment(c *gin.Context) {
+	note := s.attachmentNote(c)
+	if note == nil {
+		return
+	}
+	attachment := s.lookupAttachment(c, note)
+	if attachment == nil {
+		return
+	}
+
+	if err := s.db.DeleteAttachment(attachment.ID); err != nil {
+		log.Printf("Failed to delete attachment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete attachment",
+		})
+		return
+	}
+
+	s.releaseAttachments(note, []models.Attachment{*attachment})
+	s.syncToVault(nil, note)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Attachment deleted",
+		"id":      attachment.ID,
+	})
+}
+
+// lookupAttachment loads an attachment of a note by the request's attachmentId
+func (s *Server) lookupAttachment(c *gin.Context, note *models.ProcessedNote) *models.Attachment {
+	attachment, err := s.db.GetAttachment(note.ID, c.Param("attachmentId"))
+	if err != nil {
+		log.Printf("Failed to get attachment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve attachment",
+		})
+		return nil
+	}
+
+	if attachment == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Attachment not found",
+		})
+		return nil
+	}
+
+	return attachment
+}
+
+// deleteAttachments removes the attachments of a deleted note
+func (s *Server) deleteAttachments(note *models.ProcessedNote) {
+	if s.attachments == nil {
+		return
+	}
+
+	attachments, err := s.db.DeleteNoteAttachments(note.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to delete attachments of %s: %v", note.ID, err)
+		return
+	}
+	s.releaseAttachments(note, attachments)
+}
+
+// releaseAttachments removes the files of deleted attachments that no remaining attachment
+// shares: the copy in the note's vault, and the stored content once nothing uses it
+func (s *Server) releaseAttachments(note *models.ProcessedNote, attachments []models.Attachment) {
+	s.attachmentMu.Lock()
+	defer s.attachmentMu.Unlock()
+
+	s.releaseContent(note, attachments)
+}
+
+// releaseContent is releaseAttachments for a caller that holds attachmentMu, so no upload
+// stores the same content between the check for remaining attachments and the removal
+func (s *Server) releaseContent(note *models.ProcessedNote, attachments []models.Attachment) {
+	for _, attachment := range attachments {
+		remaining, err := s.db.ContentAttachments(attachment.SHA256)
+		if err != nil {
+			log.Printf("Warning: Keeping files of attachment %s: %v", attachment.ID, err)
+			continue
+		}
+
+		if s.vaults != nil {
+			if err := s.vaults.For(note).RemoveAttachment(&attachment, remaining); err != nil {
+				log.Printf("Warning: Failed to remove attachment %s from the vault: %v", attachment.ID, err)
+			}
+		}
+
+		if len(remaining) == 0 {
+			if err := s.attachments.Remove(attachment.SHA256); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// vaultSnapshot reads every file below a vault, by relative path
+func vaultSnapshot(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestCommandDryRunLeavesVaultUntouched(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	vault := filepath.Join(dir, "vault")
+	if err := os.MkdirAll(filepath.Join(vault, "Notes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(vault, "Notes", "garden.md"), []byte("# Garden\n\nPlant tomatoes.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DATABASE_PATH", filepath.Join(dir, "ideaforge.db"))
+	t.Setenv("OBSIDIAN_VAULT_PATH", vault)
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	// A note without a file, so both the resync and the index refresh have something to write
+	server := NewCommandServer()
+	note := &models.ProcessedNote{ID: "note_test1234", Title: "Rack", Category: "homelab", Markdown: "# Rack", Original: "rack", CreatedAt: time.Now()}
+	if err := server.db.CreateNote(note, models.RevisionSourceImport); err != nil {
+		t.Fatal(err)
+	}
+	before := vaultSnapshot(t, vault)
+
+	report, err := server.Resync(models.ResyncOptions{DryRun: true, Orphans: models.OrphansImport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Summary[models.DriftMissing] != 1 {
+		t.Errorf("resync report = %+v, want the missing note", report)
+	}
+
+	imports, err := server.ImportVault(context.Background(), models.ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imports.Summary[models.ImportPlanned] != 1 {
+		t.Errorf("import report = %+v, want one planned import", imports)
+	}
+
+	server.Close()
+	server.db.Close()
+
+	after := vaultSnapshot(t, vault)
+	if len(after) != len(before) {
+		t.Errorf("vault files after dry run = %v, want %v", snapshotPaths(after), snapshotPaths(before))
+	}
+	for path, content := range before {
+		if after[path] != content {
+			t.Errorf("%s changed by the dry run:\n%s", path, after[path])
+		}
+	}
+}
+
+func snapshotPaths(m map[string]string) []string {
+	var result []string
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
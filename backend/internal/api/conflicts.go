@@ -0,0 +1,308 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/diff"
+	"github.com/kilo40/idea-forge/internal/models"
+	"github.com/kilo40/idea-forge/internal/storage"
+)
+
+// Sides of a conflict that can be kept when resolving it
+const (
+	keepDatabase = "database" // the note as stored in the database
+	keepFile     = "file"     // the note file Syncthing kept
+	keepConflict = "conflict" // the conflict copy with the other device's edit
+	keepMerged   = "merged"   // a three-way merge, or content supplied by the client
+)
+
+// conflictMarker starts a conflict block left by diff.Merge3
+const conflictMarker = "<<<<<<< "
+
+// errMergeConflicts is returned when a merged version still contains conflict blocks
+var errMergeConflicts = errors.New("merged version still has conflicts; resolve them and send the content")
+
+// conflictVersions holds the three versions of a conflicted note, normalized for comparison
+type conflictVersions struct {
+	database string
+	file     string
+	conflict string
+}
+
+// listConflicts handles GET /api/conflicts
+// Each Syncthing conflict copy is shown with diffs of both sides against the database version
+// and an automatic three-way merge.
+func (s *Server) listConflicts(c *gin.Context) {
+	if s.db == nil || s.vaults == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Conflict detection requires the database and the Obsidian vault",
+		})
+		return
+	}
+
+	conflicts, err := s.scanConflicts()
+	if err != nil {
+		log.Printf("Failed to scan for conflicts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to scan for conflicts",
+		})
+		return
+	}
+
+	results := make([]gin.H, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		note, err := s.db.GetNote(conflict.NoteID)
+		if err != nil {
+			log.Printf("Failed to get note for conflict %s: %v", conflict.Path, err)
+			continue
+		}
+
+		result := gin.H{
+			"vault":         conflict.Vault,
+			"path":          conflict.Path,
+			"original_path": conflict.OriginalPath,
+			"note_id":       conflict.NoteID,
+			"device":        conflict.Device,
+			"detected_at":   conflict.DetectedAt,
+		}
+
+		if note != nil {
+			versions, err := s.conflictVersions(s.vaults.Named(conflict.Vault), note, &conflict)
+			if err != nil {
+				log.Printf("Failed to read conflict %s: %v", conflict.Path, err)
+				continue
+			}
+
+			merged, count := diff.Merge3(versions.database, versions.file, versions.conflict, keepFile, keepConflict)
+			result["title"] = note.Title
+			result["file_diff"] = diff.Unified(keepDatabase, keepFile, versions.database, versions.file)
+			result["conflict_diff"] = diff.Unified(keepDatabase, keepConflict, versions.database, versions.conflict)
+			result["merged"] = merged
+			result["merge_conflicts"] = count
+		}
+
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conflicts": results,
+		"total":     len(results),
+	})
+}
+
+// resolveConflict handles POST /api/conflicts/resolve
+// The body names the conflict copy, with its vault when several are configured, and the side
+// to keep: database, file, conflict or merged.
+// With merged, content may carry a hand-merged version; otherwise the automatic merge is used.
+func (s *Server) resolveConflict(c *gin.Context) {
+	if s.db == nil || s.vaults == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Conflict resolution requires the database and the Obsidian vault",
+		})
+		return
+	}
+
+	var input struct {
+		Vault   string `json:"vault"`
+		Path    string `json:"path" binding:"required"`
+		Keep    string `json:"keep" binding:"required"`
+		Content string `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	switch input.Keep {
+	case keepDatabase, keepFile, keepConflict, keepMerged:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid keep, expected database, file, conflict or merged",
+		})
+		return
+	}
+
+	conflicts, err := s.scanConflicts()
+	if err != nil {
+		log.Printf("Failed to scan for conflicts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to resolve conflict",
+		})
+		return
+	}
+
+	var conflict *storage.ConflictFile
+	for i := range conflicts {
+		if conflicts[i].Path == input.Path && (input.Vault == "" || conflicts[i].Vault == input.Vault) {
+			conflict = &conflicts[i]
+		}
+	}
+	if conflict == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Conflict not found",
+		})
+		return
+	}
+
+	previous, err := s.db.GetNote(conflict.NoteID)
+	if err != nil || previous == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	vault := s.vaults.Named(conflict.Vault)
+	note := *previous
+	if input.Keep != keepDatabase {
+		content, err := s.chosenContent(vault, previous, conflict, input.Keep, input.Content)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Failed to resolve conflict",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		if err := s.applyVaultContent(vault, &note, conflict.OriginalPath, content); err != nil {
+			log.Printf("Failed to apply resolved conflict: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to resolve conflict",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	s.noteSaved(previous, &note)
+
+	if err := vault.RemoveFile(conflict.Path); err != nil {
+		log.Printf("Failed to remove conflict file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove conflict file",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
+// scanConflicts finds the Syncthing conflict copies in every vault folder
+func (s *Server) scanConflicts() ([]storage.ConflictFile, error) {
+	var conflicts []storage.ConflictFile
+	for _, w := range s.vaults.All() {
+		found, err := w.ScanConflicts()
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, found...)
+	}
+	return conflicts, nil
+}
+
+// conflictVersions reads the database, file and conflict versions of a note from the vault
+// folder holding the conflict
+func (s *Server) conflictVersions(vault *storage.ObsidianWriter, note *models.ProcessedNote, conflict *storage.ConflictFile) (*conflictVersions, error) {
+	database, err := vault.RenderNote(note)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := vault.ReadFile(conflict.OriginalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	conflictCopy, err := vault.ReadFile(conflict.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conflictVersions{
+		database: storage.ComparableContent(database),
+		file:     storage.ComparableContent(file),
+		conflict: storage.ComparableContent(conflictCopy),
+	}, nil
+}
+
+// chosenContent returns the file content for the side of a conflict being kept
+func (s *Server) chosenContent(vault *storage.ObsidianWriter, note *models.ProcessedNote, conflict *storage.ConflictFile, keep, content string) (string, error) {
+	if keep == keepMerged && content != "" {
+		if strings.Contains(content, conflictMarker) {
+			return "", errMergeConflicts
+		}
+		return content, nil
+	}
+
+	versions, err := s.conflictVersions(vault, note, conflict)
+	if err != nil {
+		return "", err
+	}
+
+	switch keep {
+	case keepFile:
+		return versions.file, nil
+	case keepConflict:
+		return versions.conflict, nil
+	default:
+		merged, count := diff.Merge3(versions.database, versions.file, versions.conflict, keepFile, keepConflict)
+		if count > 0 {
+			return "", errMergeConflicts
+		}
+		return merged, nil
+	}
+}
+
+// applyVaultContent writes content to a note's file and takes the edited fields into the note,
+// recording a revision when something changed
+func (s *Server) applyVaultContent(vault *storage.ObsidianWriter, note *models.ProcessedNote, path, content string) error {
+	parsed, err := vault.ParseNote(path, content)
+	if err != nil {
+		return err
+	}
+
+	// The file keeps any hand-added frontmatter, which the rewrite that follows preserves
+	if err := vault.WriteFile(path, content); err != nil {
+		return err
+	}
+	note.VaultPath = path
+
+	changed := parsed.Title != note.Title || parsed.Markdown != note.Markdown ||
+		!sameLinks(parsed.Links, note.Links) || parsed.Status != note.Status
+
+	note.Title = parsed.Title
+	note.Markdown = parsed.Markdown
+	note.Links = parsed.Links
+	if len(parsed.TaskDetails) > 0 {
+		note.TaskDetails = parsed.TaskDetails
+	}
+	if parsed.Status != note.Status {
+		note.SetStatus(parsed.Status, time.Now())
+	}
+
+	if !changed {
+		return nil
+	}
+	return s.db.UpdateNote(note, models.RevisionSourceConflict)
+}
+
+// sameLinks reports whether two link lists point to the same resources
+func sameLinks(a, b []models.Link) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].URL != b[i].URL || a[i].Title != b[i].Title || a[i].Description != b[i].Description {
+			return false
+		}
+	}
+	return true
+}
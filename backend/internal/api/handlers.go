@@ -13,6 +13,7 @@ import (
 
 // createNote handles POST /api/notes
 // This is the main flow: input -> LLM expansion -> search links -> save -> sync to Obsidian
+// Input containing several distinct ideas produces one note per idea unless "split" is false.
 func (s *Server) createNote(c *gin.Context) {
 	var input models.NoteInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -41,66 +42,228 @@ func (s *Server) createNote(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
 	defer cancel()
 
-	// Step 1: Expand note with LLM
+	// Step 1: Expand note with LLM, using related existing notes as context
+	related := s.findRelatedContext(input.Content)
+	if len(related) > 0 {
+		log.Printf("Found %d related notes for context", len(related))
+	}
+
 	log.Printf("Expanding note: %s", input.Content)
-	llmResponse, err := s.llm.ExpandNote(ctx, input.Content)
-	if err != nil {
-		log.Printf("LLM expansion failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to expand note",
-			"details": err.Error(),
-		})
-		return
+	var llmResponses []models.LLMResponse
+	if input.ShouldSplit() {
+		responses, err := s.llm.ExpandIdeas(ctx, input.Content, related)
+		if err != nil {
+			log.Printf("LLM expansion failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to expand note",
+				"details": err.Error(),
+			})
+			return
+		}
+		llmResponses = responses
+	} else {
+		response, err := s.llm.ExpandNote(ctx, input.Content, related)
+		if err != nil {
+			log.Printf("LLM expansion failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to expand note",
+				"details": err.Error(),
+			})
+			return
+		}
+		llmResponses = []models.LLMResponse{*response}
 	}
 
+	if len(llmResponses) > 1 {
+		log.Printf("Input split into %d notes", len(llmResponses))
+	}
+
+	notes := make([]*models.ProcessedNote, 0, len(llmResponses))
+	duplicates := make([]models.DuplicateWarning, 0)
+	for i := range llmResponses {
+		original := llmResponses[i].Original
+		if original == "" || len(llmResponses) == 1 {
+			original = input.Content
+		}
+
+		// Look for near-identical notes before this one is indexed itself
+		duplicate := s.findDuplicate(llmResponses[i].Title + "\n" + llmResponses[i].Markdown)
+
+		note := s.processNote(c.Request.Context(), original, &llmResponses[i])
+		notes = append(notes, note)
+
+		if duplicate != nil {
+			log.Printf("Note %s looks like a duplicate of %s (score %.2f)", note.ID, duplicate.ID, duplicate.Score)
+			duplicates = append(duplicates, models.DuplicateWarning{
+				NoteID:      note.ID,
+				DuplicateOf: *duplicate,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notes":      notes,
+		"total":      len(notes),
+		"duplicates": duplicates,
+	})
+}
+
+// processNote turns a single LLM expansion into a saved note: search links -> save -> sync to Obsidian
+func (s *Server) processNote(ctx context.Context, original string, llmResponse *models.LLMResponse) *models.ProcessedNote {
 	// Step 2: Search for relevant links (optional - don't fail if search unavailable)
 	links := make([]models.Link, 0) // Initialize as empty slice, not nil (nil serializes to null in JSON)
 	if s.search != nil {
+		searchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
 		log.Printf("Searching for links: %s", llmResponse.Title)
-		searchLinks, err := s.search.SearchForLinks(ctx, llmResponse.Title)
+		searchLinks, err := s.search.SearchForLinks(searchCtx, llmResponse.Title)
 		if err != nil {
 			log.Printf("Search failed (continuing without links): %v", err)
-		} else {
+		} else if searchLinks != nil {
 			links = searchLinks
 		}
 	}
 
+	relatedIDs := llmResponse.Related
+	if relatedIDs == nil {
+		relatedIDs = []string{}
+	}
+
 	// Build the processed note
 	now := time.Now()
 	note := &models.ProcessedNote{
-		Original:  input.Content,
-		Title:     llmResponse.Title,
-		Category:  llmResponse.Category,
-		Markdown:  llmResponse.Markdown,
-		Links:     links,
-		CreatedAt: now,
+		Original:    original,
+		Title:       llmResponse.Title,
+		Category:    llmResponse.Category,
+		Markdown:    llmResponse.Markdown,
+		Links:       links,
+		RelatedIDs:  relatedIDs,
+		TaskDetails: llmResponse.Tasks,
+		CreatedAt:   now,
 	}
+	s.similarRelated(note)
 
 	// Step 3: Save to database (optional - don't fail if db unavailable)
 	if s.db != nil {
-		if err := s.db.CreateNote(note); err != nil {
+		if err := s.db.CreateNote(note, models.RevisionSourceAPI); err != nil {
 			log.Printf("Database save failed (continuing): %v", err)
 		} else {
 			log.Printf("Note saved to database: %s", note.ID)
 		}
 	}
 
-	// Step 4: Write to Obsidian vault (optional - don't fail if not configured)
-	if s.obsidian != nil {
-		if err := s.obsidian.WriteNote(note); err != nil {
-			log.Printf("Obsidian write failed (continuing): %v", err)
-		} else {
-			syncTime := time.Now()
-			note.SyncedAt = &syncTime
-			log.Printf("Note written to Obsidian: %s/%s", note.Category, note.Title)
+	// Step 4: Index and write to Obsidian vault (optional - don't fail if not configured)
+	s.noteSaved(nil, note)
+
+	// Step 5: Link the new note from today's daily note
+	if s.vaults != nil {
+		if err := s.vaults.For(note).AppendToDailyNote(note); err != nil {
+			log.Printf("Daily note update failed (continuing): %v", err)
+		}
+	}
+
+	return note
+}
+
+// updateNote handles PUT /api/notes/:id
+func (s *Server) updateNote(c *gin.Context) {
+	if s.db == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	var update models.NoteUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if update.Category != nil && !models.IsValidCategory(*update.Category) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid category",
+		})
+		return
+	}
+
+	if update.Status != nil && !models.IsValidStatus(*update.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status",
+		})
+		return
+	}
 
-			// Update sync time in database
-			if s.db != nil {
-				s.db.UpdateSyncedAt(note.ID, syncTime)
+	if update.TaskDetails != nil {
+		for _, details := range *update.TaskDetails {
+			if err := details.Validate(); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "Invalid task details",
+					"details": err.Error(),
+				})
+				return
 			}
 		}
 	}
 
+	if update.Title != nil && *update.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Title cannot be empty",
+		})
+		return
+	}
+
+	id := c.Param("id")
+	previous, err := s.db.GetNote(id)
+	if err != nil {
+		log.Printf("Failed to get note for update: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update note",
+		})
+		return
+	}
+
+	if previous == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	note := *previous
+	if update.Title != nil {
+		note.Title = *update.Title
+	}
+	if update.Category != nil {
+		note.Category = *update.Category
+	}
+	if update.Markdown != nil {
+		note.Markdown = *update.Markdown
+	}
+	if update.Links != nil {
+		note.Links = *update.Links
+	}
+	if update.TaskDetails != nil {
+		note.TaskDetails = *update.TaskDetails
+	}
+	if update.Status != nil {
+		note.SetStatus(*update.Status, time.Now())
+	}
+
+	if err := s.db.UpdateNote(&note, models.RevisionSourceAPI); err != nil {
+		log.Printf("Failed to update note: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update note",
+		})
+		return
+	}
+
+	s.noteSaved(previous, &note)
+
 	c.JSON(http.StatusOK, note)
 }
 
@@ -199,12 +362,8 @@ func (s *Server) deleteNote(c *gin.Context) {
 		return
 	}
 
-	// Delete from Obsidian vault
-	if s.obsidian != nil {
-		if err := s.obsidian.DeleteNote(note); err != nil {
-			log.Printf("Failed to delete from Obsidian (note removed from db): %v", err)
-		}
-	}
+	// Remove from the similarity index and Obsidian vault
+	s.noteDeleted(note)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Note deleted",
@@ -238,3 +397,157 @@ func (s *Server) listCategories(c *gin.Context) {
 		"categories": categories,
 	})
 }
+
+// noteSaved updates everything derived from a note after it was created or changed
+func (s *Server) noteSaved(previous, note *models.ProcessedNote) {
+	s.indexNote(note)
+	s.embedNote(note)
+	s.syncToVault(previous, note)
+	s.syncRelated(previous, note)
+	s.syncToSinks(previous, note)
+
+	if s.vaultIndexes != nil {
+		s.vaultIndexes.Trigger()
+	}
+}
+
+// noteDeleted removes everything derived from a note after it was deleted from the database
+func (s *Server) noteDeleted(note *models.ProcessedNote) {
+	if s.similarity != nil {
+		s.similarity.Remove(note.ID)
+	}
+	s.dropEmbedding(note.ID)
+
+	if s.vaults != nil {
+		if err := s.vaults.For(note).DeleteNote(note); err != nil {
+			s.enqueueVaultOp(models.VaultOpDelete, note.ID, note, err)
+		}
+	}
+	s.unlinkRelated(note)
+	s.deleteAttachments(note)
+
+	for _, sink := range s.sinks {
+		if err := sink.DeleteNote(note); err != nil {
+			log.Printf("Warning: Failed to delete note %s from %s: %v", note.ID, sink.Name(), err)
+		}
+	}
+
+	if s.vaultIndexes != nil {
+		s.vaultIndexes.Trigger()
+	}
+}
+
+// refreshVaultIndexes regenerates the category indexes, Map of Content and canvases in the vault.
+// Runs are serialized, since the debouncer, resync and import may all start one.
+func (s *Server) refreshVaultIndexes() {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+
+	notes, err := s.db.AllNotes()
+	if err != nil {
+		log.Printf("Failed to load notes for vault indexes: %v", err)
+		return
+	}
+
+	routed := s.vaults.Partition(notes)
+	for _, w := range s.vaults.All() {
+		if err := w.WriteIndexes(routed[w]); err != nil {
+			log.Printf("Failed to write vault indexes of %s: %v", w.VaultName(), err)
+		}
+
+		if err := w.WriteCanvases(routed[w]); err != nil {
+			log.Printf("Failed to write vault canvases of %s: %v", w.VaultName(), err)
+		}
+	}
+}
+
+// syncToVault writes a note to the Obsidian vault and records the sync time.
+// If previous is set and the note's file location changed, the old file is moved first.
+// Failed operations are queued in the outbox and retried in the background.
+func (s *Server) syncToVault(previous, note *models.ProcessedNote) {
+	if s.vaults == nil {
+		return
+	}
+
+	written := false
+	if previous != nil {
+		var err error
+		if written, err = s.relocateNote(previous, note); err != nil {
+			s.enqueueVaultOp(models.VaultOpMove, note.ID, previous, err)
+			return
+		}
+	}
+
+	if !written {
+		if err := s.writeToVault(note); err != nil {
+			s.enqueueVaultOp(models.VaultOpWrite, note.ID, nil, err)
+			return
+		}
+	}
+
+	log.Printf("Note written to Obsidian: %s/%s", note.Category, note.Title)
+}
+
+// relocateNote moves a note's file after its title, category or vault changed. A note routed
+// to another vault is written there, keeping its hand-added frontmatter, before the old file
+// is removed, so a failed write leaves the old file in place; written reports whether the
+// note was written.
+func (s *Server) relocateNote(previous, note *models.ProcessedNote) (written bool, err error) {
+	from, to := s.vaults.For(previous), s.vaults.For(note)
+	if from == to {
+		if previous.Title != note.Title || previous.Category != note.Category {
+			return false, to.MoveNote(previous, note)
+		}
+		return false, nil
+	}
+
+	moved, err := from.ReadNoteFile(previous)
+	if err != nil {
+		return false, err
+	}
+
+	note.VaultPath = ""
+	if err := to.WriteMovedNote(note, moved); err != nil {
+		return false, err
+	}
+	s.markSynced(note)
+
+	return true, from.DeleteNote(previous)
+}
+
+// syncToSinks writes a note to the configured sinks besides Obsidian, moving it first if its
+// title or category changed. Failures are logged; the next save of the note writes it again.
+func (s *Server) syncToSinks(previous, note *models.ProcessedNote) {
+	for _, sink := range s.sinks {
+		if previous != nil && (previous.Title != note.Title || previous.Category != note.Category) {
+			if err := sink.MoveNote(previous, note); err != nil {
+				log.Printf("Warning: Failed to move note %s in %s: %v", note.ID, sink.Name(), err)
+			}
+		}
+
+		if err := sink.WriteNote(note); err != nil {
+			log.Printf("Warning: Failed to write note %s to %s: %v", note.ID, sink.Name(), err)
+		}
+	}
+}
+
+// writeToVault writes a note file and records the sync time on the note and in the database
+func (s *Server) writeToVault(note *models.ProcessedNote) error {
+	if err := s.vaults.For(note).WriteNote(note); err != nil {
+		return err
+	}
+
+	s.markSynced(note)
+	return nil
+}
+
+// markSynced records the sync time of a note written to the vault
+func (s *Server) markSynced(note *models.ProcessedNote) {
+	syncTime := time.Now()
+	note.SyncedAt = &syncTime
+
+	// Update sync time in database
+	if s.db != nil {
+		s.db.UpdateSyncedAt(note.ID, syncTime, note.VaultPath)
+	}
+}
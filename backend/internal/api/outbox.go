@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+const (
+	// outboxInterval is how often pending vault operations are retried
+	outboxInterval = 30 * time.Second
+	// outboxBatchSize bounds the operations handled per run
+	outboxBatchSize = 50
+	// outboxBaseBackoff and outboxMaxBackoff bound the exponential retry delay
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = time.Hour
+)
+
+// enqueueVaultOp queues a vault operation that failed so the outbox worker retries it
+func (s *Server) enqueueVaultOp(op string, noteID string, previous *models.ProcessedNote, cause error) {
+	if s.db == nil {
+		return
+	}
+
+	item := &models.VaultOp{NoteID: noteID, Op: op, Previous: previous, LastError: cause.Error()}
+	if err := s.db.EnqueueVaultOp(item); err != nil {
+		log.Printf("Failed to queue vault %s for %s: %v", op, noteID, err)
+		return
+	}
+
+	log.Printf("Vault %s for %s queued for retry: %v", op, noteID, cause)
+}
+
+// drainOutbox retries due vault operations in order. Once an operation of a note fails or is
+// still backing off, later operations of the same note wait so they are applied in sequence.
+func (s *Server) drainOutbox(ctx context.Context) error {
+	ops, err := s.db.PendingVaultOps(outboxBatchSize)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	blocked := make(map[string]bool)
+	for _, op := range ops {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if blocked[op.NoteID] || op.NextAttemptAt.After(now) {
+			blocked[op.NoteID] = true
+			continue
+		}
+
+		if err := s.applyVaultOp(&op); err != nil {
+			blocked[op.NoteID] = true
+			next := now.Add(outboxBackoff(op.Attempts + 1))
+			if err := s.db.RetryVaultOp(op.ID, err, next); err != nil {
+				return err
+			}
+			log.Printf("Vault %s for %s failed (attempt %d, retrying at %s): %v",
+				op.Op, op.NoteID, op.Attempts+1, next.Format(time.RFC3339), err)
+			continue
+		}
+
+		if err := s.db.CompleteVaultOp(op.ID); err != nil {
+			return err
+		}
+		log.Printf("Vault %s for %s completed after %d retries", op.Op, op.NoteID, op.Attempts+1)
+
+		if s.vaultIndexes != nil {
+			s.vaultIndexes.Trigger()
+		}
+	}
+
+	return nil
+}
+
+// applyVaultOp performs a queued operation against the current state of the note
+func (s *Server) applyVaultOp(op *models.VaultOp) error {
+	if op.Op == models.VaultOpDelete {
+		if op.Previous == nil {
+			return nil
+		}
+		return s.vaults.For(op.Previous).DeleteNote(op.Previous)
+	}
+
+	note, err := s.db.GetNote(op.NoteID)
+	if err != nil {
+		return err
+	}
+	if note == nil {
+		// Deleted since; its delete operation takes care of the file
+		return nil
+	}
+
+	switch op.Op {
+	case models.VaultOpMove:
+		if op.Previous != nil {
+			if written, err := s.relocateNote(op.Previous, note); err != nil || written {
+				return err
+			}
+		}
+		return s.writeToVault(note)
+	case models.VaultOpWrite:
+		return s.writeToVault(note)
+	default:
+		return fmt.Errorf("unknown vault operation %q", op.Op)
+	}
+}
+
+// outboxBackoff returns the delay before the given retry attempt
+func outboxBackoff(attempt int) time.Duration {
+	delay := outboxBaseBackoff
+	for i := 1; i < attempt && delay < outboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, outboxMaxBackoff)
+}
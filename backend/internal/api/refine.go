@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// refineHistoryTurns is the number of earlier refinement messages replayed with an instruction
+const refineHistoryTurns = 6
+
+// refineNote handles POST /api/notes/:id/refine
+// The LLM revises the current markdown following the instruction; the result becomes a new revision.
+func (s *Server) refineNote(c *gin.Context) {
+	var input models.RefineInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	input.Instruction = strings.TrimSpace(input.Instruction)
+	if input.Instruction == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Instruction is required",
+		})
+		return
+	}
+
+	if s.llm == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "LLM service not configured. Set ANTHROPIC_API_KEY.",
+		})
+		return
+	}
+
+	if s.db == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	id := c.Param("id")
+	previous, err := s.db.GetNote(id)
+	if err != nil {
+		log.Printf("Failed to get note for refinement: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to refine note",
+		})
+		return
+	}
+
+	if previous == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	history, err := s.db.ListConversation(id)
+	if err != nil {
+		log.Printf("Failed to load refinement conversation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to refine note",
+		})
+		return
+	}
+
+	// Only the latest exchanges are replayed, so refining a note many times doesn't grow every prompt.
+	// The current markdown is sent anyway, so earlier replies are replayed by their summary.
+	if len(history) > refineHistoryTurns {
+		history = history[len(history)-refineHistoryTurns:]
+	}
+	for i := range history {
+		if history[i].Role == "assistant" {
+			history[i].Content = refineTurnSummary(history[i].Content)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	log.Printf("Refining note %s: %s", id, input.Instruction)
+	llmResponse, err := s.llm.RefineNote(ctx, previous.Original, previous.Markdown, previous.Category, input.Instruction, history)
+	if err != nil {
+		log.Printf("LLM refinement failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to refine note",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// A reply without a title or markdown would wipe the note
+	if strings.TrimSpace(llmResponse.Title) == "" || strings.TrimSpace(llmResponse.Markdown) == "" {
+		log.Printf("LLM refinement of %s returned an empty title or markdown", id)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Failed to refine note",
+			"details": "the LLM returned an empty title or markdown",
+		})
+		return
+	}
+
+	note := *previous
+	note.Title = llmResponse.Title
+	note.Category = llmResponse.Category
+	note.Markdown = llmResponse.Markdown
+	if len(llmResponse.Tasks) > 0 {
+		note.TaskDetails = llmResponse.Tasks
+	}
+
+	if err := s.db.UpdateNote(&note, models.RevisionSourceRegenerate); err != nil {
+		log.Printf("Failed to save refined note: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to refine note",
+		})
+		return
+	}
+
+	// Keep the exchange so later refinements build on it
+	now := time.Now()
+	if err := s.db.AppendConversation(id,
+		models.ConversationTurn{Role: "user", Content: input.Instruction, CreatedAt: now},
+		models.ConversationTurn{Role: "assistant", Content: refineSummary(llmResponse), CreatedAt: now},
+	); err != nil {
+		log.Printf("Failed to store refinement conversation (continuing): %v", err)
+	}
+
+	s.noteSaved(previous, &note)
+
+	c.JSON(http.StatusOK, note)
+}
+
+// refineSummary describes a refinement reply without repeating the note it produced
+func refineSummary(response *models.LLMResponse) string {
+	done, total := models.TaskProgress(response.Markdown)
+	return fmt.Sprintf("Revised the note %q (%s), now with %d checklist items, %d done.",
+		response.Title, response.Category, total, done)
+}
+
+// refineTurnSummary shortens a stored reply to its summary. Replies stored before summaries
+// were kept hold the whole JSON note.
+func refineTurnSummary(content string) string {
+	var response models.LLMResponse
+	if err := json.Unmarshal([]byte(content), &response); err != nil || response.Markdown == "" {
+		return content
+	}
+	return refineSummary(&response)
+}
@@ -0,0 +1,16 @@
+package api
+
+import "testing"
+
+func TestRefineTurnSummary(t *testing.T) {
+	stored := `{"title":"Backup plan","category":"homelab","markdown":"# Backup plan\n\n- [x] Buy disks\n- [ ] Test restore"}`
+	want := `Revised the note "Backup plan" (homelab), now with 2 checklist items, 1 done.`
+	if got := refineTurnSummary(stored); got != want {
+		t.Errorf("refineTurnSummary(JSON reply) = %q, want %q", got, want)
+	}
+
+	// Summaries are stored as they are replayed
+	if got := refineTurnSummary(want); got != want {
+		t.Errorf("refineTurnSummary(summary) = %q, want it unchanged", got)
+	}
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"log"
+	"slices"
+	"sort"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+const (
+	// relatedContextLimit is the number of existing notes given to the LLM as context
+	relatedContextLimit = 3
+	// relatedContextTasks is the number of tasks listed for each related note
+	relatedContextTasks = 8
+	// relatedSimilarLimit is the number of similar notes linked as related to a new note
+	relatedSimilarLimit = 3
+	// defaultRelatedThreshold is the similarity above which an existing note is linked as related
+	defaultRelatedThreshold = 0.3
+)
+
+// findRelatedContext retrieves the existing notes most related to the input, summarized for the LLM.
+// The similarity index is preferred; keyword search is the fallback when it is not available.
+func (s *Server) findRelatedContext(content string) []models.RelatedNote {
+	if s.db == nil {
+		return nil
+	}
+
+	if s.similarity != nil && s.similarity.Len() > 0 {
+		var notes []models.ProcessedNote
+		for _, match := range s.similarity.Query(content, relatedContextLimit) {
+			note, err := s.db.GetNote(match.ID)
+			if err != nil || note == nil {
+				continue
+			}
+			notes = append(notes, *note)
+		}
+		return summarizeRelated(notes)
+	}
+
+	notes, err := s.db.SearchNotes(content, relatedContextLimit)
+	if err != nil {
+		log.Printf("Related note search failed (continuing without context): %v", err)
+		return nil
+	}
+
+	return summarizeRelated(notes)
+}
+
+// summarizeRelated reduces notes to their title and key tasks
+func summarizeRelated(notes []models.ProcessedNote) []models.RelatedNote {
+	related := make([]models.RelatedNote, 0, len(notes))
+	for _, note := range notes {
+		summary := models.RelatedNote{
+			ID:       note.ID,
+			Title:    note.Title,
+			Category: note.Category,
+		}
+		for _, task := range models.ExtractTasks(note.Markdown) {
+			if len(summary.Tasks) >= relatedContextTasks {
+				break
+			}
+			summary.Tasks = append(summary.Tasks, task.Text)
+		}
+		related = append(related, summary)
+	}
+	return related
+}
+
+// similarRelated adds the existing notes most similar to a new note to the related notes the
+// LLM picked, so notes are linked even when the LLM didn't build on them
+func (s *Server) similarRelated(note *models.ProcessedNote) {
+	for _, match := range s.similarNotes(noteText(note), relatedSimilarLimit, note.ID) {
+		if match.Score >= s.relatedThreshold && !slices.Contains(note.RelatedIDs, match.ID) {
+			note.RelatedIDs = append(note.RelatedIDs, match.ID)
+		}
+	}
+}
+
+// relatedLinks looks up the notes listed in a note's Related section: the notes it relates to
+// and the notes that relate to it, sorted by title. Notes routed to another vault are left
+// out, since a wikilink can't reach them.
+func (s *Server) relatedLinks(note *models.ProcessedNote) []models.ProcessedNote {
+	var notes []models.ProcessedNote
+	seen := map[string]bool{note.ID: true}
+
+	for _, id := range note.RelatedIDs {
+		if seen[id] {
+			continue
+		}
+		related, err := s.db.GetNote(id)
+		if err != nil {
+			log.Printf("Warning: Failed to look up related note %s: %v", id, err)
+			continue
+		}
+		if related != nil {
+			seen[id] = true
+			notes = append(notes, *related)
+		}
+	}
+
+	if note.ID != "" {
+		backlinks, err := s.db.Backlinks(note.ID)
+		if err != nil {
+			log.Printf("Warning: Failed to look up backlinks of note %s: %v", note.ID, err)
+		}
+		for _, backlink := range backlinks {
+			if !seen[backlink.ID] {
+				seen[backlink.ID] = true
+				notes = append(notes, backlink)
+			}
+		}
+	}
+
+	vault := s.vaults.For(note)
+	notes = slices.DeleteFunc(notes, func(related models.ProcessedNote) bool {
+		return s.vaults.For(&related) != vault
+	})
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].Title < notes[j].Title
+	})
+	return notes
+}
+
+// syncRelated rewrites the files of the notes whose Related section lists a saved note, so
+// backlinks follow new relations and title or location changes. Notes that the previous
+// version related to are rewritten as well, in case the relation was dropped.
+func (s *Server) syncRelated(previous, note *models.ProcessedNote) {
+	if s.vaults == nil || s.db == nil || note.ID == "" {
+		return
+	}
+
+	if previous != nil && previous.Title == note.Title && previous.Category == note.Category &&
+		slices.Equal(previous.RelatedIDs, note.RelatedIDs) {
+		return
+	}
+
+	ids := append([]string{}, note.RelatedIDs...)
+	if previous != nil {
+		ids = append(ids, previous.RelatedIDs...)
+
+		backlinks, err := s.db.Backlinks(note.ID)
+		if err != nil {
+			log.Printf("Warning: Failed to look up backlinks of note %s: %v", note.ID, err)
+		}
+		for _, backlink := range backlinks {
+			ids = append(ids, backlink.ID)
+		}
+	}
+
+	s.rewriteNotes(note.ID, ids)
+}
+
+// unlinkRelated removes a deleted note from the related notes of the others and rewrites
+// the files that linked to it
+func (s *Server) unlinkRelated(note *models.ProcessedNote) {
+	if s.db == nil {
+		return
+	}
+
+	ids, err := s.db.RemoveRelated(note.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to remove note %s from related notes: %v", note.ID, err)
+	}
+
+	if s.vaults != nil {
+		s.rewriteNotes(note.ID, append(ids, note.RelatedIDs...))
+	}
+}
+
+// rewriteNotes writes the current version of each listed note to the vault once, skipping the
+// note that caused the rewrite
+func (s *Server) rewriteNotes(cause string, ids []string) {
+	slices.Sort(ids)
+	for _, id := range slices.Compact(ids) {
+		if id == cause {
+			continue
+		}
+		note, err := s.db.GetNote(id)
+		if err != nil {
+			log.Printf("Warning: Failed to load related note %s: %v", id, err)
+			continue
+		}
+		if note != nil {
+			s.syncToVault(nil, note)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/models"
+	"github.com/kilo40/idea-forge/internal/storage"
+)
+
+// vaultFile is a note file found in one of the vault folders
+type vaultFile struct {
+	storage.VaultFile
+	vault *storage.ObsidianWriter
+}
+
+// Resync compares the notes in the database with the note files in the vaults by frontmatter id
+// and repairs the drift: missing and stale files are rewritten, misplaced files are moved, also
+// to another vault after a route changed, and orphaned files are imported or quarantined if
+// asked to. With DryRun set it only reports.
+func (s *Server) Resync(opts models.ResyncOptions) (*models.ResyncReport, error) {
+	if s.db == nil || s.vaults == nil {
+		return nil, fmt.Errorf("resync requires both the database and the Obsidian vault")
+	}
+	if !models.IsValidOrphanMode(opts.Orphans) {
+		return nil, fmt.Errorf("invalid orphans mode %q", opts.Orphans)
+	}
+
+	notes, err := s.db.AllNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	filesByID := make(map[string][]vaultFile)
+	total := 0
+	for _, w := range s.vaults.All() {
+		files, err := w.ScanNotes()
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			filesByID[file.ID] = append(filesByID[file.ID], vaultFile{file, w})
+		}
+		total += len(files)
+	}
+
+	report := &models.ResyncReport{
+		DryRun:  opts.DryRun,
+		Notes:   len(notes),
+		Files:   total,
+		Actions: []models.ResyncAction{},
+		Summary: make(map[string]int),
+	}
+	changed := false
+
+	for i := range notes {
+		note := &notes[i]
+		vault := s.vaults.For(note)
+		target := vault.NotePath(note)
+		noteFiles := filesByID[note.ID]
+		delete(filesByID, note.ID)
+		if file := vault.AdoptedFile(note); file != nil {
+			noteFiles = append(noteFiles, vaultFile{*file, vault})
+		}
+
+		// Prefer the file at the expected path, then any file in the right vault; any other copy is a duplicate
+		current := -1
+		for j, file := range noteFiles {
+			if file.vault == vault && (file.Path == target || current == -1) {
+				current = j
+			}
+		}
+		if current == -1 && len(noteFiles) > 0 {
+			current = 0
+		}
+
+		for j, file := range noteFiles {
+			if j != current {
+				action := models.ResyncAction{Drift: models.DriftDuplicate, NoteID: note.ID, Vault: file.vault.VaultName(), Path: file.Path}
+				changed = s.resolveStrayFile(file.vault, &action, nil, opts) || changed
+				report.Add(action)
+			}
+		}
+
+		action := models.ResyncAction{NoteID: note.ID, Vault: vault.VaultName(), Target: target}
+		switch {
+		case current == -1:
+			action.Drift = models.DriftMissing
+		case noteFiles[current].vault != vault:
+			action.Drift = models.DriftMoved
+			action.Vault, action.Path = noteFiles[current].vault.VaultName(), noteFiles[current].Path
+			action.TargetVault = vault.VaultName()
+		case noteFiles[current].Path != target:
+			action.Drift = models.DriftMoved
+			action.Path = noteFiles[current].Path
+		case note.SyncedAt == nil || note.UpdatedAt.After(*note.SyncedAt):
+			action.Drift = models.DriftStale
+			action.Path = target
+		default:
+			continue
+		}
+
+		switch {
+		case opts.DryRun:
+			action.Result = models.ResyncPlanned
+		default:
+			var err error
+			if action.Drift == models.DriftMoved {
+				err = s.moveVaultFile(noteFiles[current], vault, target)
+				note.VaultPath = target
+			}
+			if err == nil {
+				err = s.writeToVault(note)
+			}
+			if err == nil && action.TargetVault != "" {
+				// The note now has its file in the vault it is routed to
+				err = noteFiles[current].vault.RemoveFile(action.Path)
+			}
+			action.Finish(err, models.ResyncWritten)
+			changed = true
+		}
+		report.Add(action)
+	}
+
+	// Whatever is left has no note in the database
+	for id, orphanFiles := range filesByID {
+		for _, file := range orphanFiles {
+			action := models.ResyncAction{Drift: models.DriftOrphan, NoteID: id, Vault: file.vault.VaultName(), Path: file.Path}
+			changed = s.resolveStrayFile(file.vault, &action, &file.VaultFile, opts) || changed
+			report.Add(action)
+		}
+	}
+
+	if changed && s.vaultIndexes != nil {
+		s.refreshVaultIndexes()
+	}
+
+	return report, nil
+}
+
+// moveVaultFile moves a note file within its vault folder. A file in another vault is left
+// for the caller to remove once the note was written where it is routed.
+func (s *Server) moveVaultFile(file vaultFile, vault *storage.ObsidianWriter, target string) error {
+	if file.vault != vault {
+		return nil
+	}
+	return vault.MoveFile(file.Path, target)
+}
+
+// resolveStrayFile handles a file that does not belong to a note: orphans can be imported,
+// and both orphans and duplicates can be quarantined. It reports whether the vault changed.
+func (s *Server) resolveStrayFile(vault *storage.ObsidianWriter, action *models.ResyncAction, orphan *storage.VaultFile, opts models.ResyncOptions) bool {
+	switch {
+	case opts.Orphans == models.OrphansKeep:
+		action.Result = models.ResyncSkipped
+		return false
+	case opts.DryRun:
+		action.Result = models.ResyncPlanned
+		return false
+	case opts.Orphans == models.OrphansImport && orphan != nil:
+		note, err := s.importVaultFile(vault, orphan.Path)
+		if note != nil {
+			routed := s.vaults.For(note)
+			action.Target = routed.NotePath(note)
+			if routed != vault {
+				action.TargetVault = routed.VaultName()
+			}
+		}
+		action.Finish(err, models.ResyncImported)
+		return err == nil
+	case opts.Orphans == models.OrphansQuarantine:
+		target, err := vault.QuarantineFile(action.Path)
+		action.Target = target
+		action.Finish(err, models.ResyncQuarantined)
+		return err == nil
+	default:
+		action.Result = models.ResyncSkipped
+		return false
+	}
+}
+
+// importVaultFile creates a note from a file in a vault folder, keeping the id in its
+// frontmatter, and rewrites the file where the note belongs. A file whose note is routed to
+// another vault is written there and removed from the folder it was found in.
+func (s *Server) importVaultFile(vault *storage.ObsidianWriter, path string) (*models.ProcessedNote, error) {
+	note, err := vault.ReadNote(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !models.IsValidCategory(note.Category) {
+		note.Category = "personal"
+	}
+
+	routed := s.vaults.For(note)
+	target := routed.NotePath(note)
+
+	if err := s.db.CreateNote(note, models.RevisionSourceImport); err != nil {
+		return note, err
+	}
+
+	if routed != vault {
+		note.VaultPath = ""
+	} else if err := vault.MoveFile(path, target); err != nil {
+		log.Printf("Failed to move imported file %s (continuing): %v", path, err)
+	} else {
+		note.VaultPath = target
+	}
+
+	s.indexNote(note)
+	s.embedNote(note)
+	if err := s.writeToVault(note); err != nil {
+		return note, err
+	}
+
+	if routed != vault {
+		if err := vault.RemoveFile(path); err != nil {
+			log.Printf("Failed to remove imported file %s from %s (continuing): %v", path, vault.VaultName(), err)
+		}
+	}
+
+	return note, nil
+}
+
+// resync handles POST /api/admin/resync
+// The optional body is {"dry_run": true, "orphans": "import" | "quarantine"}.
+func (s *Server) resync(c *gin.Context) {
+	var opts models.ResyncOptions
+	if err := c.ShouldBindJSON(&opts); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !models.IsValidOrphanMode(opts.Orphans) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid orphans mode, expected import or quarantine",
+		})
+		return
+	}
+
+	if s.db == nil || s.vaults == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Resync requires the database and the Obsidian vault",
+		})
+		return
+	}
+
+	report, err := s.Resync(opts)
+	if err != nil {
+		log.Printf("Vault resync failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to resync vault",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+const (
+	// defaultReviewSchedule runs the weekly review on Sunday at 18:00
+	defaultReviewSchedule = "0 18 * * 0"
+	// defaultReviewStaleDays is how long an active note may go untouched before it counts as stale
+	defaultReviewStaleDays = 14
+)
+
+// reviewStaleAfter returns the configured staleness window (REVIEW_STALE_DAYS)
+func reviewStaleAfter() time.Duration {
+	days := defaultReviewStaleDays
+	if value, err := strconv.Atoi(os.Getenv("REVIEW_STALE_DAYS")); err == nil && value > 0 {
+		days = value
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// gatherReviewData collects note activity for the ISO week containing now
+func (s *Server) gatherReviewData(now time.Time) (*models.ReviewData, error) {
+	notes, err := s.db.AllNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	start := models.WeekStart(now)
+	staleBefore := now.Add(-reviewStaleAfter())
+
+	data := &models.ReviewData{
+		Week:        models.ISOWeekID(now),
+		PeriodStart: start,
+		PeriodEnd:   now,
+		Created:     []models.ReviewNote{},
+		Completed:   []models.ReviewNote{},
+		Stale:       []models.ReviewNote{},
+		OpenTasks:   make(map[string]int),
+	}
+
+	for i := range notes {
+		note := &notes[i]
+		summary := models.NewReviewNote(note)
+
+		if !note.CreatedAt.Before(start) && !note.CreatedAt.After(now) {
+			data.Created = append(data.Created, summary)
+		}
+
+		if note.CompletedAt != nil && !note.CompletedAt.Before(start) && !note.CompletedAt.After(now) {
+			data.Completed = append(data.Completed, summary)
+		}
+
+		if note.Status == models.StatusActive {
+			data.OpenTasks[note.Category] += summary.OpenTasks
+			if note.UpdatedAt.Before(staleBefore) {
+				data.Stale = append(data.Stale, summary)
+			}
+		}
+	}
+
+	// Oldest stale notes first, they need attention the most
+	sort.Slice(data.Stale, func(i, j int) bool {
+		return data.Stale[i].UpdatedAt.Before(data.Stale[j].UpdatedAt)
+	})
+
+	return data, nil
+}
+
+// runWeeklyReview generates the review for the current week, stores it and writes it to the vault
+func (s *Server) runWeeklyReview(ctx context.Context) (*models.Review, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+
+	now := time.Now()
+	data, err := s.gatherReviewData(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather review data: %w", err)
+	}
+
+	// The LLM summary is optional; the stats below are always included
+	summary := ""
+	if s.llm != nil {
+		summary, err = s.llm.WriteReview(ctx, data)
+		if err != nil {
+			log.Printf("LLM review summary failed (continuing with stats only): %v", err)
+			summary = ""
+		}
+	}
+
+	review := &models.Review{
+		ID:          data.Week,
+		PeriodStart: data.PeriodStart,
+		PeriodEnd:   data.PeriodEnd,
+		Markdown:    renderReview(data, summary),
+		CreatedAt:   now,
+	}
+
+	if s.vaults != nil {
+		if err := s.vaults.Default().WriteReview(review); err != nil {
+			log.Printf("Obsidian review write failed (continuing): %v", err)
+		} else {
+			syncTime := time.Now()
+			review.SyncedAt = &syncTime
+		}
+	}
+
+	if err := s.db.SaveReview(review); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Weekly review %s generated", review.ID)
+	return review, nil
+}
+
+// renderReview builds the review markdown from the LLM summary and the gathered stats
+func renderReview(data *models.ReviewData, summary string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("# Weekly Review %s\n\n", data.Week))
+	sb.WriteString(fmt.Sprintf("*%s to %s*\n\n", data.PeriodStart.Format("2006-01-02"), data.PeriodEnd.Format("2006-01-02")))
+
+	if summary != "" {
+		sb.WriteString(strings.TrimSpace(summary))
+		sb.WriteString("\n\n")
+	}
+
+	sb.WriteString("## Stats\n\n")
+
+	writeList := func(heading string, notes []models.ReviewNote) {
+		sb.WriteString(fmt.Sprintf("### %s (%d)\n\n", heading, len(notes)))
+		if len(notes) == 0 {
+			sb.WriteString("- none\n\n")
+			return
+		}
+		for _, n := range notes {
+			sb.WriteString(fmt.Sprintf("- %s (%s) - %d/%d tasks done\n", n.Title, n.Category, n.DoneTasks, n.DoneTasks+n.OpenTasks))
+		}
+		sb.WriteString("\n")
+	}
+
+	writeList("Created", data.Created)
+	writeList("Completed", data.Completed)
+	writeList("Stale", data.Stale)
+
+	sb.WriteString("### Open Tasks by Category\n\n")
+	sb.WriteString("| Category | Open tasks |\n")
+	sb.WriteString("| --- | --- |\n")
+	for _, category := range models.ValidCategories {
+		sb.WriteString(fmt.Sprintf("| %s | %d |\n", category, data.OpenTasks[category]))
+	}
+
+	return sb.String()
+}
+
+// listReviews handles GET /api/reviews
+func (s *Server) listReviews(c *gin.Context) {
+	if s.db == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"reviews": []models.Review{},
+			"total":   0,
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	reviews, total, err := s.db.ListReviews(limit, offset)
+	if err != nil {
+		log.Printf("Failed to list reviews: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve reviews",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"total":   total,
+	})
+}
+
+// createReview handles POST /api/reviews
+// Generates the review for the current week immediately instead of waiting for the schedule.
+func (s *Server) createReview(c *gin.Context) {
+	if s.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Database not available",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 90*time.Second)
+	defer cancel()
+
+	review, err := s.runWeeklyReview(ctx)
+	if err != nil {
+		log.Printf("Failed to generate review: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to generate review",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
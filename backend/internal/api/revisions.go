@@ -0,0 +1,216 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/diff"
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// listRevisions handles GET /api/notes/:id/revisions
+func (s *Server) listRevisions(c *gin.Context) {
+	if s.db == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	id := c.Param("id")
+	revisions, err := s.db.ListRevisions(id)
+	if err != nil {
+		log.Printf("Failed to list revisions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve revisions",
+		})
+		return
+	}
+
+	if len(revisions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": revisions,
+		"total":     len(revisions),
+	})
+}
+
+// diffRevisions handles GET /api/notes/:id/revisions/diff?from=1&to=2
+// Defaults to comparing the latest revision with the one before it.
+func (s *Server) diffRevisions(c *gin.Context) {
+	if s.db == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	id := c.Param("id")
+	latest, err := s.db.LatestRevision(id)
+	if err != nil {
+		log.Printf("Failed to get latest revision: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to compute diff",
+		})
+		return
+	}
+
+	if latest == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	to, err := strconv.Atoi(c.DefaultQuery("to", strconv.Itoa(latest)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid 'to' revision",
+		})
+		return
+	}
+
+	from, err := strconv.Atoi(c.DefaultQuery("from", strconv.Itoa(max(to-1, 1))))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid 'from' revision",
+		})
+		return
+	}
+
+	fromRev, err := s.db.GetRevision(id, from)
+	if err != nil {
+		log.Printf("Failed to get revision: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to compute diff",
+		})
+		return
+	}
+
+	toRev, err := s.db.GetRevision(id, to)
+	if err != nil {
+		log.Printf("Failed to get revision: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to compute diff",
+		})
+		return
+	}
+
+	if fromRev == nil || toRev == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Revision not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"note_id": id,
+		"from":    from,
+		"to":      to,
+		"diff": diff.Unified(
+			fmt.Sprintf("%s@%d", id, from),
+			fmt.Sprintf("%s@%d", id, to),
+			revisionText(fromRev),
+			revisionText(toRev),
+		),
+	})
+}
+
+// revertRevision handles POST /api/notes/:id/revisions/:rev/revert
+// The note is restored to the given revision, which is recorded as a new revision.
+func (s *Server) revertRevision(c *gin.Context) {
+	if s.db == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	id := c.Param("id")
+	revNumber, err := strconv.Atoi(c.Param("rev"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid revision",
+		})
+		return
+	}
+
+	previous, err := s.db.GetNote(id)
+	if err != nil {
+		log.Printf("Failed to get note for revert: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revert note",
+		})
+		return
+	}
+
+	if previous == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	rev, err := s.db.GetRevision(id, revNumber)
+	if err != nil {
+		log.Printf("Failed to get revision for revert: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revert note",
+		})
+		return
+	}
+
+	if rev == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Revision not found",
+		})
+		return
+	}
+
+	note := *previous
+	note.Title = rev.Title
+	note.Category = rev.Category
+	note.Markdown = rev.Markdown
+	note.Links = rev.Links
+	note.TaskDetails = rev.TaskDetails
+
+	if err := s.db.UpdateNote(&note, models.RevisionSourceRevert); err != nil {
+		log.Printf("Failed to revert note: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revert note",
+		})
+		return
+	}
+
+	s.noteSaved(previous, &note)
+
+	c.JSON(http.StatusOK, note)
+}
+
+// revisionText renders a revision as plain text for diffing
+func revisionText(rev *models.NoteRevision) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("title: %s\n", rev.Title))
+	sb.WriteString(fmt.Sprintf("category: %s\n\n", rev.Category))
+	sb.WriteString(strings.TrimRight(rev.Markdown, "\n"))
+	sb.WriteString("\n")
+
+	if len(rev.Links) > 0 {
+		sb.WriteString("\n## Resources\n\n")
+		for _, link := range rev.Links {
+			sb.WriteString(fmt.Sprintf("- [%s](%s)\n", link.Title, link.URL))
+		}
+	}
+
+	return sb.String()
+}
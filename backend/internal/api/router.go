@@ -1,28 +1,71 @@
 package api
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/embeddings"
 	"github.com/kilo40/idea-forge/internal/llm"
+	"github.com/kilo40/idea-forge/internal/scheduler"
 	"github.com/kilo40/idea-forge/internal/search"
+	"github.com/kilo40/idea-forge/internal/similarity"
 	"github.com/kilo40/idea-forge/internal/storage"
 )
 
+// vaultIndexDebounce is how long note changes must settle before the vault index notes are regenerated
+const vaultIndexDebounce = 3 * time.Second
+
+// shutdownTimeout is how long requests in flight may take to finish when the server stops
+const shutdownTimeout = 10 * time.Second
+
+// defaultDuplicateThreshold is the similarity above which a new note is reported as a likely duplicate
+const defaultDuplicateThreshold = 0.6
+
 // Server represents the API server with all dependencies
 type Server struct {
-	router   *gin.Engine
-	db       *storage.Database
-	llm      *llm.Client
-	search   *search.Client
-	obsidian *storage.ObsidianWriter
+	router             *gin.Engine
+	db                 *storage.Database
+	llm                *llm.Client
+	search             *search.Client
+	vaults             *storage.Vaults // nil when no Obsidian vault is configured
+	sinks              []storage.Sink  // destinations besides Obsidian, such as Logseq or org-mode
+	attachments        *storage.AttachmentStore
+	attachmentMu       sync.Mutex // held while attachment content is stored or released
+	similarity         *similarity.Index
+	embedder           embeddings.Provider
+	embedMu            sync.Mutex        // guards embedPending and embedSeq
+	embedPending       map[string]uint64 // latest embedding request per note, see claimEmbedding
+	embedSeq           uint64
+	scheduler          *scheduler.Scheduler
+	vaultIndexes       *scheduler.Debouncer
+	indexMu            sync.Mutex // held while the vault indexes are regenerated
+	duplicateThreshold float64
+	relatedThreshold   float64
+	askThreshold       float64
 }
 
 // NewServer creates a new API server instance with all dependencies
 func NewServer() *Server {
+	return newServer(true)
+}
+
+// NewCommandServer creates a server for a one-shot command such as resync or import. It skips
+// the startup work of a long-running server, the vault index refresh and the embedding
+// backfill, so a dry run leaves the vault and the providers alone. The commands refresh the
+// indexes themselves when they change the vault.
+func NewCommandServer() *Server {
+	return newServer(false)
+}
+
+// newServer creates a server; background starts the work a long-running server does on startup
+func newServer(background bool) *Server {
 	router := gin.Default()
 
 	// Configure CORS for frontend
@@ -39,7 +82,35 @@ func NewServer() *Server {
 	}))
 
 	s := &Server{
-		router: router,
+		router:             router,
+		embedPending:       make(map[string]uint64),
+		duplicateThreshold: defaultDuplicateThreshold,
+		relatedThreshold:   defaultRelatedThreshold,
+		askThreshold:       defaultAskThreshold,
+	}
+
+	if threshold := os.Getenv("SIMILARITY_THRESHOLD"); threshold != "" {
+		if value, err := strconv.ParseFloat(threshold, 64); err != nil || value <= 0 || value > 1 {
+			log.Printf("Warning: Invalid SIMILARITY_THRESHOLD %q, using %.2f", threshold, defaultDuplicateThreshold)
+		} else {
+			s.duplicateThreshold = value
+		}
+	}
+
+	if threshold := os.Getenv("RELATED_THRESHOLD"); threshold != "" {
+		if value, err := strconv.ParseFloat(threshold, 64); err != nil || value <= 0 || value > 1 {
+			log.Printf("Warning: Invalid RELATED_THRESHOLD %q, using %.2f", threshold, defaultRelatedThreshold)
+		} else {
+			s.relatedThreshold = value
+		}
+	}
+
+	if threshold := os.Getenv("ASK_THRESHOLD"); threshold != "" {
+		if value, err := strconv.ParseFloat(threshold, 64); err != nil || value <= 0 || value > 1 {
+			log.Printf("Warning: Invalid ASK_THRESHOLD %q, using %.2f", threshold, defaultAskThreshold)
+		} else {
+			s.askThreshold = value
+		}
 	}
 
 	// Initialize dependencies (log errors but don't fail - allows partial functionality)
@@ -49,6 +120,26 @@ func NewServer() *Server {
 		s.db = db
 	}
 
+	if s.db != nil {
+		s.similarity = similarity.NewIndex()
+		s.loadSimilarityIndex()
+
+		if provider, err := embeddings.NewProvider(); err != nil {
+			log.Printf("Warning: Embeddings provider initialization failed: %v", err)
+		} else {
+			s.embedder = provider
+			if background {
+				go s.backfillEmbeddings()
+			}
+		}
+
+		if store, err := storage.NewAttachmentStore(); err != nil {
+			log.Printf("Warning: Attachment store initialization failed: %v", err)
+		} else {
+			s.attachments = store
+		}
+	}
+
 	if llmClient, err := llm.NewClient(); err != nil {
 		log.Printf("Warning: LLM client initialization failed: %v", err)
 	} else {
@@ -61,10 +152,43 @@ func NewServer() *Server {
 		s.search = searchClient
 	}
 
-	if obsidianWriter, err := storage.NewObsidianWriter(); err != nil {
+	if vaults, err := storage.NewVaults(); err != nil {
 		log.Printf("Warning: Obsidian writer initialization failed: %v", err)
 	} else {
-		s.obsidian = obsidianWriter
+		s.vaults = vaults
+	}
+
+	if s.vaults != nil && s.db != nil {
+		s.vaults.SetRelated(s.relatedLinks)
+		if s.attachments != nil {
+			s.vaults.SetAttachments(s.attachments, s.noteAttachments)
+		}
+	}
+
+	s.sinks = storage.ConfiguredSinks()
+
+	if s.vaults != nil && s.db != nil {
+		s.vaultIndexes = scheduler.NewDebouncer(vaultIndexDebounce, s.refreshVaultIndexes)
+		if background {
+			s.vaultIndexes.Trigger()
+		}
+	}
+
+	s.scheduler = scheduler.New()
+	if s.vaults != nil && s.db != nil {
+		s.scheduler.Every("vault-outbox", outboxInterval, s.drainOutbox)
+	}
+	if s.db != nil {
+		spec := os.Getenv("REVIEW_SCHEDULE")
+		if spec == "" {
+			spec = defaultReviewSchedule
+		}
+		if err := s.scheduler.Add("weekly-review", spec, func(ctx context.Context) error {
+			_, err := s.runWeeklyReview(ctx)
+			return err
+		}); err != nil {
+			log.Printf("Warning: Weekly review not scheduled: %v", err)
+		}
 	}
 
 	s.setupRoutes()
@@ -79,23 +203,95 @@ func (s *Server) setupRoutes() {
 		api.GET("/health", s.healthCheck)
 		api.POST("/notes", s.createNote)
 		api.GET("/notes", s.listNotes)
+		api.GET("/notes/semantic", s.semanticSearch)
 		api.GET("/notes/:id", s.getNote)
+		api.PUT("/notes/:id", s.updateNote)
 		api.DELETE("/notes/:id", s.deleteNote)
+		api.GET("/notes/:id/revisions", s.listRevisions)
+		api.GET("/notes/:id/revisions/diff", s.diffRevisions)
+		api.POST("/notes/:id/revisions/:rev/revert", s.revertRevision)
+		api.POST("/notes/:id/refine", s.refineNote)
+		api.GET("/notes/:id/related", s.relatedNotes)
+		api.POST("/notes/:id/merge", s.mergeNote)
+		api.GET("/notes/:id/attachments", s.listAttachments)
+		api.POST("/notes/:id/attachments", s.uploadAttachments)
+		api.GET("/notes/:id/attachments/:attachmentId", s.getAttachment)
+		api.DELETE("/notes/:id/attachments/:attachmentId", s.deleteAttachment)
 		api.GET("/categories", s.listCategories)
+		api.POST("/ask", s.askNotes)
+		api.GET("/reviews", s.listReviews)
+		api.POST("/reviews", s.createReview)
+		api.POST("/admin/resync", s.resync)
+		api.POST("/admin/import", s.importVault)
+		api.GET("/conflicts", s.listConflicts)
+		api.POST("/conflicts/resolve", s.resolveConflict)
 	}
 
 	// Same routes at root level (for Tailscale serve which strips /api/ prefix)
 	s.router.GET("/health", s.healthCheck)
 	s.router.POST("/notes", s.createNote)
 	s.router.GET("/notes", s.listNotes)
+	s.router.GET("/notes/semantic", s.semanticSearch)
 	s.router.GET("/notes/:id", s.getNote)
+	s.router.PUT("/notes/:id", s.updateNote)
 	s.router.DELETE("/notes/:id", s.deleteNote)
+	s.router.GET("/notes/:id/revisions", s.listRevisions)
+	s.router.GET("/notes/:id/revisions/diff", s.diffRevisions)
+	s.router.POST("/notes/:id/revisions/:rev/revert", s.revertRevision)
+	s.router.POST("/notes/:id/refine", s.refineNote)
+	s.router.GET("/notes/:id/related", s.relatedNotes)
+	s.router.POST("/notes/:id/merge", s.mergeNote)
+	s.router.GET("/notes/:id/attachments", s.listAttachments)
+	s.router.POST("/notes/:id/attachments", s.uploadAttachments)
+	s.router.GET("/notes/:id/attachments/:attachmentId", s.getAttachment)
+	s.router.DELETE("/notes/:id/attachments/:attachmentId", s.deleteAttachment)
 	s.router.GET("/categories", s.listCategories)
+	s.router.POST("/ask", s.askNotes)
+	s.router.GET("/reviews", s.listReviews)
+	s.router.POST("/reviews", s.createReview)
+	s.router.POST("/admin/resync", s.resync)
+	s.router.POST("/admin/import", s.importVault)
+	s.router.GET("/conflicts", s.listConflicts)
+	s.router.POST("/conflicts/resolve", s.resolveConflict)
+}
+
+// Run starts the scheduler and the HTTP server, and shuts both down once ctx is cancelled.
+// Requests in flight get shutdownTimeout to finish.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	s.scheduler.Start()
+	defer s.scheduler.Stop()
+
+	srv := &http.Server{Addr: addr, Handler: s.router}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
 }
 
-// Run starts the HTTP server
-func (s *Server) Run(addr string) error {
-	return s.router.Run(addr)
+// Close finishes background vault work: a pending index refresh runs now, one already
+// running is waited for, and batched vault changes are committed to git
+func (s *Server) Close() {
+	if s.vaultIndexes != nil {
+		s.vaultIndexes.Flush()
+		// Wait for a refresh that was already running
+		s.indexMu.Lock()
+		s.indexMu.Unlock()
+	}
+
+	if s.vaults != nil {
+		s.vaults.FlushCommits()
+	}
 }
 
 // healthCheck returns server health status
@@ -108,12 +304,32 @@ func (s *Server) healthCheck(c *gin.Context) {
 
 	// Add component status
 	components := gin.H{
-		"database": s.db != nil,
-		"llm":      s.llm != nil,
-		"search":   s.search != nil,
-		"obsidian": s.obsidian != nil,
+		"database":    s.db != nil,
+		"llm":         s.llm != nil,
+		"search":      s.search != nil,
+		"obsidian":    s.vaults != nil,
+		"embeddings":  s.embedder != nil,
+		"attachments": s.attachments != nil,
 	}
 	status["components"] = components
 
+	sinks := make([]string, 0, len(s.sinks))
+	for _, sink := range s.sinks {
+		sinks = append(sinks, sink.Name())
+	}
+	status["sinks"] = sinks
+
+	if s.vaults != nil {
+		status["vaults"] = s.vaults.Health()
+	}
+
+	if s.db != nil {
+		if outbox, err := s.db.OutboxStats(); err != nil {
+			log.Printf("Failed to read vault outbox: %v", err)
+		} else {
+			status["outbox"] = outbox
+		}
+	}
+
 	c.JSON(http.StatusOK, status)
 }
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/embeddings"
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// embeddingBatchSize is the number of notes embedded per provider call during backfill
+const embeddingBatchSize = 16
+
+// backfillEmbeddings embeds every note that has no vector from the current provider yet
+func (s *Server) backfillEmbeddings() {
+	notes, err := s.db.NotesWithoutEmbedding(s.embedder.Name())
+	if err != nil {
+		log.Printf("Warning: Embedding backfill failed: %v", err)
+		return
+	}
+
+	if len(notes) == 0 {
+		return
+	}
+
+	log.Printf("Backfilling embeddings for %d notes with %s", len(notes), s.embedder.Name())
+	for start := 0; start < len(notes); start += embeddingBatchSize {
+		batch := notes[start:min(start+embeddingBatchSize, len(notes))]
+
+		texts := make([]string, len(batch))
+		seqs := make([]uint64, len(batch))
+		for i := range batch {
+			texts[i] = noteText(&batch[i])
+			seqs[i] = s.claimEmbedding(batch[i].ID)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		vectors, err := s.embedder.Embed(ctx, texts)
+		cancel()
+		if err != nil {
+			for i := range batch {
+				s.storeEmbedding(batch[i].ID, seqs[i], nil)
+			}
+			log.Printf("Warning: Embedding backfill stopped: %v", err)
+			return
+		}
+
+		for i := range batch {
+			if err := s.storeEmbedding(batch[i].ID, seqs[i], vectors[i]); err != nil {
+				log.Printf("Warning: Failed to store embedding for %s: %v", batch[i].ID, err)
+			}
+		}
+	}
+	log.Printf("Embedding backfill complete")
+}
+
+// embedNote computes and stores the embedding of a note in the background
+func (s *Server) embedNote(note *models.ProcessedNote) {
+	if s.embedder == nil || s.db == nil || note.ID == "" {
+		return
+	}
+
+	id, text := note.ID, noteText(note)
+	seq := s.claimEmbedding(id)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		vectors, err := s.embedder.Embed(ctx, []string{text})
+		if err != nil {
+			s.storeEmbedding(id, seq, nil)
+			log.Printf("Embedding failed for %s (continuing): %v", id, err)
+			return
+		}
+
+		if err := s.storeEmbedding(id, seq, vectors[0]); err != nil {
+			log.Printf("Failed to store embedding for %s: %v", id, err)
+		}
+	}()
+}
+
+// claimEmbedding registers a new embedding request for a note. Requests may finish out of
+// order, so only the latest one of a note is stored.
+func (s *Server) claimEmbedding(id string) uint64 {
+	s.embedMu.Lock()
+	defer s.embedMu.Unlock()
+
+	s.embedSeq++
+	s.embedPending[id] = s.embedSeq
+	return s.embedSeq
+}
+
+// dropEmbedding forgets the embedding requests of a deleted note, so none is stored after it
+func (s *Server) dropEmbedding(id string) {
+	s.embedMu.Lock()
+	defer s.embedMu.Unlock()
+
+	delete(s.embedPending, id)
+}
+
+// storeEmbedding saves the vector of a claimed request unless a newer request of the note
+// replaced it. A nil vector only releases the claim of a failed request.
+func (s *Server) storeEmbedding(id string, seq uint64, vector []float32) error {
+	s.embedMu.Lock()
+	defer s.embedMu.Unlock()
+
+	if s.embedPending[id] != seq {
+		return nil
+	}
+	delete(s.embedPending, id)
+
+	if vector == nil {
+		return nil
+	}
+	return s.db.SaveEmbedding(id, s.embedder.Name(), vector)
+}
+
+// semanticMatches embeds the query and ranks stored notes by cosine similarity
+func (s *Server) semanticMatches(ctx context.Context, query string, limit int) ([]models.SimilarNote, error) {
+	vectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.db.AllEmbeddings(s.embedder.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		id    string
+		score float64
+	}
+
+	ranked := make([]scored, 0, len(stored))
+	for id, vector := range stored {
+		ranked = append(ranked, scored{id: id, score: embeddings.Cosine(vectors[0], vector)})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	results := make([]models.SimilarNote, 0, limit)
+	for _, r := range ranked {
+		if len(results) >= limit || r.score <= 0 {
+			break
+		}
+		note, err := s.db.GetNote(r.id)
+		if err != nil || note == nil {
+			continue
+		}
+		results = append(results, models.SimilarNote{
+			ID:       note.ID,
+			Title:    note.Title,
+			Category: note.Category,
+			Score:    r.score,
+		})
+	}
+	return results, nil
+}
+
+// semanticSearch handles GET /api/notes/semantic?q=
+func (s *Server) semanticSearch(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Query parameter 'q' is required",
+		})
+		return
+	}
+
+	if s.db == nil || s.embedder == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Semantic search not available",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	results, err := s.semanticMatches(ctx, query, limit)
+	if err != nil {
+		log.Printf("Semantic search failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Semantic search failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":  results,
+		"total":    len(results),
+		"provider": s.embedder.Name(),
+	})
+}
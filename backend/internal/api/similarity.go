@@ -0,0 +1,265 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/models"
+	"github.com/kilo40/idea-forge/internal/similarity"
+)
+
+// noteText is the text indexed for similarity; the title is repeated to weigh it higher
+func noteText(note *models.ProcessedNote) string {
+	return note.Title + "\n" + note.Title + "\n" + note.Markdown
+}
+
+// loadSimilarityIndex indexes every stored note
+func (s *Server) loadSimilarityIndex() {
+	notes, err := s.db.AllNotes()
+	if err != nil {
+		log.Printf("Warning: Failed to load notes into similarity index: %v", err)
+		return
+	}
+
+	for i := range notes {
+		s.similarity.Add(notes[i].ID, noteText(&notes[i]))
+	}
+	log.Printf("Similarity index loaded with %d notes", len(notes))
+}
+
+// indexNote adds or refreshes a note in the similarity index
+func (s *Server) indexNote(note *models.ProcessedNote) {
+	if s.similarity == nil || note.ID == "" {
+		return
+	}
+	s.similarity.Add(note.ID, noteText(note))
+}
+
+// similarNotes resolves the closest matches for a text to stored notes, skipping the excluded ID
+func (s *Server) similarNotes(text string, limit int, exclude string) []models.SimilarNote {
+	if s.similarity == nil || s.db == nil {
+		return nil
+	}
+	return s.resolveMatches(s.similarity.Query(text, limit+1), limit, exclude)
+}
+
+// resolveMatches looks up the stored notes of index matches, skipping the excluded ID
+func (s *Server) resolveMatches(matches []similarity.Match, limit int, exclude string) []models.SimilarNote {
+	var results []models.SimilarNote
+	for _, match := range matches {
+		if match.ID == exclude {
+			continue
+		}
+		note, err := s.db.GetNote(match.ID)
+		if err != nil || note == nil {
+			continue
+		}
+		results = append(results, models.SimilarNote{
+			ID:       note.ID,
+			Title:    note.Title,
+			Category: note.Category,
+			Score:    match.Score,
+		})
+		if len(results) >= limit {
+			break
+		}
+	}
+	return results
+}
+
+// findDuplicate returns the existing note most similar to the text if it passes the duplicate threshold
+func (s *Server) findDuplicate(text string) *models.SimilarNote {
+	matches := s.similarNotes(text, 1, "")
+	if len(matches) == 0 || matches[0].Score < s.duplicateThreshold {
+		return nil
+	}
+	return &matches[0]
+}
+
+// relatedNotes handles GET /api/notes/:id/related
+func (s *Server) relatedNotes(c *gin.Context) {
+	if s.db == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	id := c.Param("id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit <= 0 {
+		limit = 5
+	}
+
+	note, err := s.db.GetNote(id)
+	if err != nil {
+		log.Printf("Failed to get note: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve related notes",
+		})
+		return
+	}
+
+	if note == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	var related []models.SimilarNote
+	if s.similarity != nil {
+		related = s.resolveMatches(s.similarity.Related(note.ID, limit), limit, note.ID)
+	}
+	if related == nil {
+		related = []models.SimilarNote{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"related": related,
+		"total":   len(related),
+	})
+}
+
+// mergeNote handles POST /api/notes/:id/merge
+// Tasks and links of the note that the target lacks are appended to it, then the note is deleted.
+func (s *Server) mergeNote(c *gin.Context) {
+	if s.db == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	var input models.MergeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	id := c.Param("id")
+	if input.Into == id {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Cannot merge a note into itself",
+		})
+		return
+	}
+
+	source, err := s.db.GetNote(id)
+	if err != nil {
+		log.Printf("Failed to get note for merge: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to merge notes",
+		})
+		return
+	}
+
+	previous, err := s.db.GetNote(input.Into)
+	if err != nil {
+		log.Printf("Failed to get merge target: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to merge notes",
+		})
+		return
+	}
+
+	if source == nil || previous == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
+
+	target := mergeNotes(previous, source)
+
+	if err := s.db.UpdateNote(target, models.RevisionSourceAPI); err != nil {
+		log.Printf("Failed to save merged note: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to merge notes",
+		})
+		return
+	}
+
+	// The merged note keeps the attachments; its file embeds them on the rewrite below
+	if s.attachments != nil {
+		if err := s.db.MoveAttachments(source.ID, target.ID); err != nil {
+			log.Printf("Warning: Failed to move attachments of merged note: %v", err)
+		}
+	}
+
+	if err := s.db.DeleteNote(source.ID); err != nil {
+		log.Printf("Failed to delete merged note: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to merge notes",
+		})
+		return
+	}
+
+	s.noteDeleted(source)
+	s.noteSaved(previous, target)
+
+	c.JSON(http.StatusOK, target)
+}
+
+// mergeNotes returns a copy of target extended with the tasks, links and related notes of source it lacks
+func mergeNotes(target, source *models.ProcessedNote) *models.ProcessedNote {
+	merged := *target
+
+	existing := make(map[string]bool)
+	for _, task := range models.ExtractTasks(target.Markdown) {
+		existing[strings.ToLower(task.Text)] = true
+	}
+
+	var newTasks []string
+	for _, task := range models.ExtractTasks(source.Markdown) {
+		if existing[strings.ToLower(task.Text)] {
+			continue
+		}
+		existing[strings.ToLower(task.Text)] = true
+
+		box := " "
+		if task.Done {
+			box = "x"
+		}
+		newTasks = append(newTasks, fmt.Sprintf("- [%s] %s", box, task.Text))
+	}
+
+	if len(newTasks) > 0 {
+		merged.Markdown = strings.TrimRight(target.Markdown, "\n") +
+			fmt.Sprintf("\n\n## Merged from: %s\n\n", source.Title) +
+			strings.Join(newTasks, "\n") + "\n"
+	}
+
+	merged.Links = append([]models.Link{}, target.Links...)
+	seenLinks := make(map[string]bool)
+	for _, link := range target.Links {
+		seenLinks[link.URL] = true
+	}
+	for _, link := range source.Links {
+		if !seenLinks[link.URL] {
+			seenLinks[link.URL] = true
+			merged.Links = append(merged.Links, link)
+		}
+	}
+
+	merged.RelatedIDs = append([]string{}, target.RelatedIDs...)
+	seenRelated := map[string]bool{target.ID: true, source.ID: true}
+	for _, relatedID := range target.RelatedIDs {
+		seenRelated[relatedID] = true
+	}
+	for _, relatedID := range source.RelatedIDs {
+		if !seenRelated[relatedID] {
+			seenRelated[relatedID] = true
+			merged.RelatedIDs = append(merged.RelatedIDs, relatedID)
+		}
+	}
+
+	return &merged
+}
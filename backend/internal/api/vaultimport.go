@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kilo40/idea-forge/internal/models"
+	"github.com/kilo40/idea-forge/internal/storage"
+)
+
+// ImportVault creates notes from the markdown files below a vault folder that IdeaForge does
+// not know yet. Each file stays where it is and is adopted by adding the new note's id to its
+// frontmatter, so importing the same folder again skips it. With Classify set the LLM picks
+// the title and category; otherwise they come from the file, defaulting to personal. Files
+// whose note would be routed to another vault fail, since they can't be adopted where they lie.
+func (s *Server) ImportVault(ctx context.Context, opts models.ImportOptions) (*models.ImportReport, error) {
+	if s.db == nil || s.vaults == nil {
+		return nil, fmt.Errorf("import requires both the database and the Obsidian vault")
+	}
+	if opts.Classify && s.llm == nil {
+		return nil, fmt.Errorf("classification requires the LLM to be configured")
+	}
+
+	vault := s.vaults.Named(opts.Vault)
+	if vault == nil {
+		return nil, fmt.Errorf("unknown vault %q", opts.Vault)
+	}
+
+	paths, err := vault.ScanImport(opts.Folder)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{
+		Vault:   vault.VaultName(),
+		Folder:  opts.Folder,
+		DryRun:  opts.DryRun,
+		Files:   len(paths),
+		Actions: []models.ImportAction{},
+		Summary: make(map[string]int),
+	}
+	imported := false
+
+	for _, path := range paths {
+		action := models.ImportAction{Path: path}
+
+		note, err := vault.ReadImport(path)
+		if err != nil {
+			action.Finish(err, "")
+			report.Add(action)
+			continue
+		}
+
+		if note.ID != "" {
+			existing, err := s.db.GetNote(note.ID)
+			if err != nil {
+				action.Finish(err, "")
+				report.Add(action)
+				continue
+			}
+			if existing != nil {
+				action.NoteID, action.Title, action.Category = existing.ID, existing.Title, existing.Category
+				action.Result = models.ImportSkipped
+				report.Add(action)
+				continue
+			}
+		}
+
+		if opts.Classify {
+			if err := s.classifyImport(ctx, note); err != nil {
+				action.Finish(err, "")
+				report.Add(action)
+				continue
+			}
+		}
+		if note.Category == "" {
+			note.Category = "personal"
+		}
+
+		action.Title, action.Category = note.Title, note.Category
+		if routed := s.vaults.For(note); routed != vault {
+			action.Finish(fmt.Errorf("note is routed to vault %s", routed.VaultName()), "")
+			report.Add(action)
+			continue
+		}
+		if opts.DryRun {
+			action.NoteID = note.ID
+			action.Result = models.ImportPlanned
+			report.Add(action)
+			continue
+		}
+
+		err = s.importFile(vault, path, note)
+		action.NoteID = note.ID
+		action.Finish(err, models.ImportImported)
+		report.Add(action)
+		imported = imported || err == nil
+	}
+
+	if imported && s.vaultIndexes != nil {
+		s.refreshVaultIndexes()
+	}
+
+	return report, nil
+}
+
+// classifyImport asks the LLM for the title and category of an imported note
+func (s *Server) classifyImport(ctx context.Context, note *models.ProcessedNote) error {
+	llmCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	classification, err := s.llm.ClassifyNote(llmCtx, note.Title, note.Markdown)
+	if err != nil {
+		return fmt.Errorf("failed to classify note: %w", err)
+	}
+
+	if classification.Title != "" {
+		note.Title = classification.Title
+	}
+	note.Category = classification.Category
+
+	return nil
+}
+
+// importFile stores an imported note and adopts its file. The note is removed again if the
+// file can't be adopted, so a later import doesn't create a second note for it.
+func (s *Server) importFile(vault *storage.ObsidianWriter, path string, note *models.ProcessedNote) error {
+	syncTime := time.Now()
+	note.VaultPath = path
+	note.SyncedAt = &syncTime
+
+	if err := s.db.CreateNote(note, models.RevisionSourceImport); err != nil {
+		return err
+	}
+
+	if err := vault.AdoptFile(path, note.ID); err != nil {
+		if delErr := s.db.DeleteNote(note.ID); delErr != nil {
+			log.Printf("Failed to remove note %s after failed import: %v", note.ID, delErr)
+		}
+		return err
+	}
+
+	s.indexNote(note)
+	s.embedNote(note)
+	s.syncToSinks(nil, note)
+
+	return nil
+}
+
+// importVault handles POST /api/admin/import
+// The optional body is {"vault": "team", "folder": "Ideas", "classify": true, "dry_run": true}.
+func (s *Server) importVault(c *gin.Context) {
+	var opts models.ImportOptions
+	if err := c.ShouldBindJSON(&opts); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if s.db == nil || s.vaults == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Import requires the database and the Obsidian vault",
+		})
+		return
+	}
+
+	if s.vaults.Named(opts.Vault) == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown vault",
+		})
+		return
+	}
+
+	if opts.Classify && s.llm == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Classification requires the LLM to be configured",
+		})
+		return
+	}
+
+	report, err := s.ImportVault(c.Request.Context(), opts)
+	if err != nil {
+		log.Printf("Vault import failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to import vault notes",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
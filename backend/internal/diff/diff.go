@@ -0,0 +1,159 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+// edit represents a single line-level operation between two texts
+type edit struct {
+	kind byte // ' ' (equal), '-' (delete), '+' (insert)
+	text string
+	a    int // position in the old text
+	b    int // position in the new text
+}
+
+// Unified returns a unified diff between two texts, or an empty string if they have the same
+// lines. A missing trailing newline is not a change.
+func Unified(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	edits := computeEdits(splitLines(a), splitLines(b))
+	if !slices.ContainsFunc(edits, func(e edit) bool { return e.kind != ' ' }) {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n", fromName))
+	sb.WriteString(fmt.Sprintf("+++ %s\n", toName))
+
+	for start := 0; start < len(edits); {
+		// Find the next change
+		for start < len(edits) && edits[start].kind == ' ' {
+			start++
+		}
+		if start >= len(edits) {
+			break
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		end := start
+		for i := start; i < len(edits); i++ {
+			if edits[i].kind != ' ' {
+				end = i
+			} else if i-end > 2*contextLines {
+				break
+			}
+		}
+
+		hunkStart := max(0, start-contextLines)
+		hunkEnd := min(len(edits), end+contextLines+1)
+		writeHunk(&sb, edits[hunkStart:hunkEnd])
+
+		start = hunkEnd
+	}
+
+	return sb.String()
+}
+
+// writeHunk writes a single hunk with its header
+func writeHunk(sb *strings.Builder, hunk []edit) {
+	aLen, bLen := 0, 0
+	for _, e := range hunk {
+		if e.kind != '+' {
+			aLen++
+		}
+		if e.kind != '-' {
+			bLen++
+		}
+	}
+
+	// Unified diff line numbers are 1-based, except for empty ranges
+	aStart, bStart := hunk[0].a, hunk[0].b
+	if aLen > 0 {
+		aStart++
+	}
+	if bLen > 0 {
+		bStart++
+	}
+
+	sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen))
+	for _, e := range hunk {
+		sb.WriteByte(e.kind)
+		sb.WriteString(e.text)
+		sb.WriteByte('\n')
+	}
+}
+
+// computeEdits builds the edit script between two line slices using a longest common subsequence
+func computeEdits(a, b []string) []edit {
+	// Trim common prefix and suffix to keep the LCS table small
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, edit{kind: ' ', text: a[i], a: i, b: i})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	n, m := len(midA), len(midB)
+
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && midA[i] == midB[j]:
+			edits = append(edits, edit{kind: ' ', text: midA[i], a: prefix + i, b: prefix + j})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{kind: '-', text: midA[i], a: prefix + i, b: prefix + j})
+			i++
+		default:
+			edits = append(edits, edit{kind: '+', text: midB[j], a: prefix + i, b: prefix + j})
+			j++
+		}
+	}
+
+	for k := 0; k < suffix; k++ {
+		ai, bi := len(a)-suffix+k, len(b)-suffix+k
+		edits = append(edits, edit{kind: ' ', text: a[ai], a: ai, b: bi})
+	}
+
+	return edits
+}
+
+// splitLines splits text into lines, ignoring a single trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
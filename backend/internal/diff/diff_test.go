@@ -0,0 +1,44 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"only the trailing newline differs", "a\nb", "a\nb\n", ""},
+		{"both empty", "", "", ""},
+		{"from empty", "", "a\nb\n", "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"to empty", "a\n", "", "--- old\n+++ new\n@@ -1,1 +0,0 @@\n-a\n"},
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"insert at start", "b\nc\n", "a\nb\nc\n", "--- old\n+++ new\n@@ -1,2 +1,3 @@\n+a\n b\n c\n"},
+		{"append at end", "a\nb", "a\nb\nc", "--- old\n+++ new\n@@ -1,2 +1,3 @@\n a\n b\n+c\n"},
+		{
+			"context is limited to three lines",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			"--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"distant changes get separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			"--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			"close changes share a hunk",
+			"1\n2\n3\n4\n5\n6\n7\n",
+			"one\n2\n3\n4\n5\n6\nseven\n",
+			"--- old\n+++ new\n@@ -1,7 +1,7 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n-7\n+seven\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := Unified("old", "new", tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: Unified =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
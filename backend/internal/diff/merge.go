@@ -0,0 +1,87 @@
+package diff
+
+import "strings"
+
+// Merge3 merges two texts that were both derived from base, line by line.
+// Changes made on only one side are applied; overlapping changes that differ are kept as
+// conflict blocks marked with aName and bName. It returns the merged text and the number of conflicts.
+func Merge3(base, a, b, aName, bName string) (string, int) {
+	baseLines := splitLines(base)
+	aLines := splitLines(a)
+	bLines := splitLines(b)
+
+	matchA := matchBase(baseLines, aLines)
+	matchB := matchBase(baseLines, bLines)
+
+	var out []string
+	conflicts := 0
+	i, ja, jb := 0, 0, 0
+
+	for i < len(baseLines) || ja < len(aLines) || jb < len(bLines) {
+		// Stable line: unchanged on both sides
+		if i < len(baseLines) && matchA[i] == ja && matchB[i] == jb {
+			out = append(out, baseLines[i])
+			i, ja, jb = i+1, ja+1, jb+1
+			continue
+		}
+
+		// Find the next base line both sides kept; everything before it is one changed chunk
+		k := i
+		for k < len(baseLines) && (matchA[k] == -1 || matchB[k] == -1) {
+			k++
+		}
+		endA, endB := len(aLines), len(bLines)
+		if k < len(baseLines) {
+			endA, endB = matchA[k], matchB[k]
+		}
+
+		baseChunk, aChunk, bChunk := baseLines[i:k], aLines[ja:endA], bLines[jb:endB]
+		switch {
+		case equalLines(aChunk, baseChunk):
+			out = append(out, bChunk...)
+		case equalLines(bChunk, baseChunk), equalLines(aChunk, bChunk):
+			out = append(out, aChunk...)
+		default:
+			conflicts++
+			out = append(out, "<<<<<<< "+aName)
+			out = append(out, aChunk...)
+			out = append(out, "=======")
+			out = append(out, bChunk...)
+			out = append(out, ">>>>>>> "+bName)
+		}
+
+		i, ja, jb = k, endA, endB
+	}
+
+	if len(out) == 0 {
+		return "", conflicts
+	}
+	return strings.Join(out, "\n") + "\n", conflicts
+}
+
+// matchBase maps each base line to its position in other, or -1 where it was removed or changed
+func matchBase(base, other []string) []int {
+	match := make([]int, len(base))
+	for i := range match {
+		match[i] = -1
+	}
+	for _, e := range computeEdits(base, other) {
+		if e.kind == ' ' {
+			match[e.a] = e.b
+		}
+	}
+	return match
+}
+
+// equalLines reports whether two line slices are identical
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package diff
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name          string
+		base, a, b    string
+		want          string
+		wantConflicts int
+	}{
+		{"no changes", "a\nb\n", "a\nb\n", "a\nb\n", "a\nb\n", 0},
+		{"change on one side", "a\nb\nc\n", "a\nB\nc\n", "a\nb\nc\n", "a\nB\nc\n", 0},
+		{"change on the other side", "a\nb\nc\n", "a\nb\nc\n", "a\nb\nC\n", "a\nb\nC\n", 0},
+		{"separate changes", "a\nb\nc\nd\ne\n", "A\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "A\nb\nc\nd\nE\n", 0},
+		{"changes one line apart", "a\nb\nc\n", "A\nb\nc\n", "a\nb\nC\n", "A\nb\nC\n", 0},
+		{"adjacent changes conflict", "a\nb\nc\n", "A\nb\nc\n", "a\nB\nc\n",
+			"<<<<<<< ours\nA\nb\n=======\na\nB\n>>>>>>> theirs\nc\n", 1},
+		{"same change on both sides", "a\nb\nc\n", "a\nB\nc\n", "a\nB\nc\n", "a\nB\nc\n", 0},
+		{"different changes to one line", "a\nb\nc\n", "a\nB\nc\n", "a\nbee\nc\n",
+			"a\n<<<<<<< ours\nB\n=======\nbee\n>>>>>>> theirs\nc\n", 1},
+		{"same deletion on both sides", "a\nb\nc\n", "a\nc\n", "a\nc\n", "a\nc\n", 0},
+		{"separate deletions", "a\nb\nc\nd\ne\n", "b\nc\nd\ne\n", "a\nb\nc\nd\n", "b\nc\nd\n", 0},
+		{"deletion against a change", "a\nb\nc\n", "a\nc\n", "a\nB\nc\n",
+			"a\n<<<<<<< ours\n=======\nB\n>>>>>>> theirs\nc\n", 1},
+		{"insert at start and end", "a\nb\n", "start\na\nb\n", "a\nb\nend\n", "start\na\nb\nend\n", 0},
+		{"different inserts at the end", "a\n", "a\nours\n", "a\ntheirs\n",
+			"a\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\n", 1},
+		{"empty base, one side adds", "", "a\nb\n", "", "a\nb\n", 0},
+		{"empty base, same text added", "", "a\n", "a\n", "a\n", 0},
+		{"empty base, different text added", "", "a\n", "b\n", "<<<<<<< ours\na\n=======\nb\n>>>>>>> theirs\n", 1},
+		{"everything deleted", "a\nb\n", "", "a\nb\n", "", 0},
+		{"repeated lines, inserts at both ends", "x\nx\nx\n", "x\nx\nx\nx\n", "y\nx\nx\nx\n", "y\nx\nx\nx\nx\n", 0},
+		// The removed copy is matched to the end, where the other side appended
+		{"repeated lines, removal next to an append", "x\nx\nx\n", "x\nx\n", "x\nx\nx\ny\n",
+			"x\nx\n<<<<<<< ours\n=======\nx\ny\n>>>>>>> theirs\n", 1},
+		{"repeated lines, changes at both ends", "- [ ] x\n- [ ] x\n- [ ] x\n- [ ] x\n",
+			"- [x] x\n- [ ] x\n- [ ] x\n- [ ] x\n", "- [ ] x\n- [ ] x\n- [ ] x\n- [x] x\n",
+			"- [x] x\n- [ ] x\n- [ ] x\n- [x] x\n", 0},
+		{"missing trailing newline", "a\nb", "a\nB", "a\nb\n", "a\nB\n", 0},
+	}
+
+	for _, tt := range tests {
+		got, conflicts := Merge3(tt.base, tt.a, tt.b, "ours", "theirs")
+		if got != tt.want || conflicts != tt.wantConflicts {
+			t.Errorf("%s: Merge3 = %d conflicts\n%s\nwant %d conflicts\n%s", tt.name, conflicts, got, tt.wantConflicts, tt.want)
+		}
+	}
+}
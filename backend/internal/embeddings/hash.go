@@ -0,0 +1,80 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// defaultHashDimensions is the vector size of the hashing provider
+const defaultHashDimensions = 256
+
+// HashProvider is a pure-Go embedding fallback based on feature hashing of words and character trigrams.
+// It needs no network or model, which makes it suitable for tests and offline setups.
+type HashProvider struct {
+	dims int
+}
+
+// NewHashProvider creates a hashing provider producing vectors of the given size
+func NewHashProvider(dims int) *HashProvider {
+	return &HashProvider{dims: dims}
+}
+
+// Name identifies the provider
+func (p *HashProvider) Name() string {
+	return fmt.Sprintf("hash:%d", p.dims)
+}
+
+// Embed hashes each text into a normalized vector
+func (p *HashProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = p.embed(text)
+	}
+	return vectors, nil
+}
+
+// embed hashes a single text
+func (p *HashProvider) embed(text string) []float32 {
+	vec := make([]float32, p.dims)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		p.add(vec, "w:"+word, 1)
+
+		// Character trigrams make related word forms land near each other
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			p.add(vec, "t:"+string(padded[i:i+3]), 0.5)
+		}
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+// add hashes a feature into a signed bucket of the vector
+func (p *HashProvider) add(vec []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	if sum&(1<<63) != 0 {
+		weight = -weight
+	}
+	vec[sum%uint64(p.dims)] += weight
+}
@@ -0,0 +1,96 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOllamaURL   = "http://localhost:11434"
+	defaultOllamaModel = "nomic-embed-text"
+)
+
+// OllamaProvider uses a local Ollama server's embedding endpoint
+type OllamaProvider struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOllamaProvider creates an Ollama provider from OLLAMA_URL and EMBEDDINGS_MODEL
+func NewOllamaProvider() (*OllamaProvider, error) {
+	baseURL := os.Getenv("OLLAMA_URL")
+	if baseURL == "" {
+		baseURL = defaultOllamaURL
+	}
+
+	model := os.Getenv("EMBEDDINGS_MODEL")
+	if model == "" {
+		model = defaultOllamaModel
+	}
+
+	return &OllamaProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}, nil
+}
+
+// Name identifies the provider and model
+func (p *OllamaProvider) Name() string {
+	return "ollama:" + p.model
+}
+
+// Embed requests vectors for all texts in a single call
+func (p *OllamaProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"model": p.model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/embed", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var ollamaResp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.Unmarshal(body, &ollamaResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(ollamaResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(ollamaResp.Embeddings))
+	}
+
+	return ollamaResp.Embeddings, nil
+}
@@ -0,0 +1,118 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOpenAIURL   = "https://api.openai.com/v1"
+	defaultOpenAIModel = "text-embedding-3-small"
+)
+
+// OpenAIProvider uses an OpenAI-compatible /embeddings API (OpenAI, LocalAI, vLLM, LM Studio, ...)
+type OpenAIProvider struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAIProvider creates a provider from EMBEDDINGS_API_URL, EMBEDDINGS_API_KEY and EMBEDDINGS_MODEL
+func NewOpenAIProvider() (*OpenAIProvider, error) {
+	baseURL := os.Getenv("EMBEDDINGS_API_URL")
+	if baseURL == "" {
+		baseURL = defaultOpenAIURL
+	}
+
+	apiKey := os.Getenv("EMBEDDINGS_API_KEY")
+	if apiKey == "" && baseURL == defaultOpenAIURL {
+		return nil, fmt.Errorf("EMBEDDINGS_API_KEY environment variable is required")
+	}
+
+	model := os.Getenv("EMBEDDINGS_MODEL")
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+
+	return &OpenAIProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}, nil
+}
+
+// Name identifies the provider and model
+func (p *OpenAIProvider) Name() string {
+	return "openai:" + p.model
+}
+
+// Embed requests vectors for all texts in a single call
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"model": p.model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/embeddings", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embeddings API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var apiResp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(apiResp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(apiResp.Data))
+	}
+
+	// The API may return items out of order; place them by index
+	vectors := make([][]float32, len(texts))
+	for _, item := range apiResp.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index out of range: %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+
+	return vectors, nil
+}
@@ -0,0 +1,52 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Provider turns text into embedding vectors
+type Provider interface {
+	// Name identifies the provider and model; vectors from different names are not comparable
+	Name() string
+	// Embed returns one vector per input text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewProvider creates the embedding provider selected by EMBEDDINGS_PROVIDER (ollama, openai or hash).
+// Semantic search stays off when nothing is configured: the hashing provider only matches shared
+// words, so it has to be chosen explicitly.
+func NewProvider() (Provider, error) {
+	switch provider := os.Getenv("EMBEDDINGS_PROVIDER"); provider {
+	case "":
+		return nil, fmt.Errorf("EMBEDDINGS_PROVIDER environment variable is required for semantic search")
+	case "ollama":
+		return NewOllamaProvider()
+	case "openai":
+		return NewOpenAIProvider()
+	case "hash":
+		return NewHashProvider(defaultHashDimensions), nil
+	default:
+		return nil, fmt.Errorf("unknown EMBEDDINGS_PROVIDER: %s", provider)
+	}
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if their sizes differ
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
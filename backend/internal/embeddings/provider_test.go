@@ -0,0 +1,197 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"identical", []float32{1, 2, 3}, []float32{1, 2, 3}, 1},
+		{"scaled", []float32{1, 2, 3}, []float32{2, 4, 6}, 1},
+		{"opposite", []float32{1, 0}, []float32{-1, 0}, -1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"different sizes", []float32{1, 0}, []float32{1, 0, 0}, 0},
+		{"empty", nil, nil, 0},
+		{"zero vector", []float32{0, 0}, []float32{1, 1}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := Cosine(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: Cosine = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHashProvider(t *testing.T) {
+	p := NewHashProvider(64)
+	if p.Name() != "hash:64" {
+		t.Errorf("Name = %q, want hash:64", p.Name())
+	}
+
+	texts := []string{"Rebuild the homelab rack", "rebuild the HOMELAB rack!", "Bake sourdough bread", ""}
+	first, err := p.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := NewHashProvider(64).Embed(context.Background(), texts)
+	if !reflect.DeepEqual(first, second) {
+		t.Error("vectors differ between providers of the same size")
+	}
+
+	if len(first) != len(texts) {
+		t.Fatalf("got %d vectors, want %d", len(first), len(texts))
+	}
+	for i, vec := range first {
+		if len(vec) != 64 {
+			t.Errorf("vector %d has %d dimensions, want 64", i, len(vec))
+		}
+	}
+
+	if got := Cosine(first[0], first[1]); math.Abs(got-1) > 1e-6 {
+		t.Errorf("case and punctuation changed the vector: cosine %v", got)
+	}
+	if Cosine(first[0], first[2]) >= Cosine(first[0], first[1]) {
+		t.Error("unrelated text is as similar as the same text")
+	}
+	if Cosine(first[0], first[3]) != 0 {
+		t.Error("empty text is not a zero vector")
+	}
+}
+
+// embeddingServer serves an embeddings endpoint, recording the request body it received
+func embeddingServer(t *testing.T, path, response string, request *map[string]any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path || r.Method != http.MethodPost {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		(*request)["authorization"] = r.Header.Get("Authorization")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOllamaProvider(t *testing.T) {
+	var request map[string]any
+	server := embeddingServer(t, "/api/embed", `{"embeddings": [[0.1, 0.2], [0.3, 0.4]]}`, &request)
+	t.Setenv("OLLAMA_URL", server.URL+"/")
+	t.Setenv("EMBEDDINGS_MODEL", "test-model")
+
+	p, err := NewOllamaProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "ollama:test-model" {
+		t.Errorf("Name = %q, want ollama:test-model", p.Name())
+	}
+
+	vectors, err := p.Embed(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{0.1, 0.2}, {0.3, 0.4}}; !reflect.DeepEqual(vectors, want) {
+		t.Errorf("vectors = %v, want %v", vectors, want)
+	}
+	if request["model"] != "test-model" || !reflect.DeepEqual(request["input"], []any{"first", "second"}) {
+		t.Errorf("request = %v", request)
+	}
+
+	if _, err := p.Embed(context.Background(), []string{"only one"}); err == nil {
+		t.Error("a response with the wrong number of vectors was accepted")
+	}
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	var request map[string]any
+	// Items may come back out of order
+	response := `{"data": [{"index": 1, "embedding": [0.3, 0.4]}, {"index": 0, "embedding": [0.1, 0.2]}]}`
+	server := embeddingServer(t, "/v1/embeddings", response, &request)
+	t.Setenv("EMBEDDINGS_API_URL", server.URL+"/v1")
+	t.Setenv("EMBEDDINGS_API_KEY", "sk-test")
+	t.Setenv("EMBEDDINGS_MODEL", "")
+
+	p, err := NewOpenAIProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "openai:"+defaultOpenAIModel {
+		t.Errorf("Name = %q, want openai:%s", p.Name(), defaultOpenAIModel)
+	}
+
+	vectors, err := p.Embed(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{0.1, 0.2}, {0.3, 0.4}}; !reflect.DeepEqual(vectors, want) {
+		t.Errorf("vectors = %v, want %v", vectors, want)
+	}
+	if request["model"] != defaultOpenAIModel || request["authorization"] != "Bearer sk-test" {
+		t.Errorf("request = %v", request)
+	}
+}
+
+func TestOpenAIProviderErrors(t *testing.T) {
+	tests := []struct {
+		name, response string
+	}{
+		{"index out of range", `{"data": [{"index": 0, "embedding": [1]}, {"index": 2, "embedding": [1]}]}`},
+		{"missing item", `{"data": [{"index": 0, "embedding": [1]}]}`},
+		{"invalid JSON", `{"data": [`},
+	}
+
+	for _, tt := range tests {
+		var request map[string]any
+		server := embeddingServer(t, "/embeddings", tt.response, &request)
+		t.Setenv("EMBEDDINGS_API_URL", server.URL)
+		t.Setenv("EMBEDDINGS_API_KEY", "")
+
+		p, err := NewOpenAIProvider()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Embed(context.Background(), []string{"first", "second"}); err == nil {
+			t.Errorf("%s: response was accepted", tt.name)
+		}
+	}
+
+	t.Setenv("EMBEDDINGS_API_URL", "")
+	if _, err := NewOpenAIProvider(); err == nil {
+		t.Error("the OpenAI API was configured without a key")
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		provider string
+		wantErr  bool
+	}{
+		{"", true},
+		{"hash", false},
+		{"ollama", false},
+		{"word2vec", true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("EMBEDDINGS_PROVIDER", tt.provider)
+		p, err := NewProvider()
+		if (err != nil) != tt.wantErr || (err == nil && p == nil) {
+			t.Errorf("NewProvider(%q) = %v, %v, want error %v", tt.provider, p, err, tt.wantErr)
+		}
+	}
+}
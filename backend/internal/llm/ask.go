@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// askNoteMaxChars limits how much of each note's markdown is sent as context
+const askNoteMaxChars = 2000
+
+// askSystemPrompt for answering questions about the user's notes
+const askSystemPrompt = `You are an assistant that answers questions about the user's own notes and plans.
+
+Each question comes with the most relevant notes, each marked with its ID in square brackets.
+Answer using only the information in those notes. Mention which tasks are done ([x]) or still open ([ ])
+when it helps. If the notes do not contain the answer, say so plainly instead of guessing.
+Earlier questions in this conversation may be referenced by follow-up questions.
+
+Respond ONLY with valid JSON in this exact format:
+{
+  "answer": "Concise answer in markdown",
+  "citations": ["note_id of each note the answer relies on"]
+}
+
+Do not include any text outside the JSON object.`
+
+// AnswerQuestion answers a question from the given notes and reports the notes it cited.
+// Earlier turns of the session are replayed so follow-up questions keep their context.
+func (c *Client) AnswerQuestion(ctx context.Context, question string, notes []models.ProcessedNote, history []models.ConversationTurn) (*models.LLMAnswer, error) {
+	messages := make([]message, 0, len(history)+1)
+	for _, turn := range history {
+		messages = append(messages, message{
+			Role:    turn.Role,
+			Content: turn.Content,
+		})
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Relevant notes:\n\n")
+	if len(notes) == 0 {
+		sb.WriteString("(no matching notes)\n\n")
+	}
+	for _, note := range notes {
+		markdown := note.Markdown
+		if len(markdown) > askNoteMaxChars {
+			markdown = strings.ToValidUTF8(markdown[:askNoteMaxChars], "") + "\n..."
+		}
+		sb.WriteString(fmt.Sprintf("[%s] %s (%s, created %s)\n%s\n\n",
+			note.ID, note.Title, note.Category, note.CreatedAt.Format("2006-01-02"), markdown))
+	}
+	sb.WriteString("Question: ")
+	sb.WriteString(question)
+
+	messages = append(messages, message{
+		Role:    "user",
+		Content: sb.String(),
+	})
+
+	responseText, err := c.sendMessages(ctx, askSystemPrompt, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	var answer models.LLMAnswer
+	if err := json.Unmarshal([]byte(responseText), &answer); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM response as JSON: %w", err)
+	}
+
+	return &answer, nil
+}
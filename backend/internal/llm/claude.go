@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -19,6 +20,16 @@ const (
 	defaultModel    = "claude-sonnet-4-20250514"
 )
 
+const (
+	// defaultMaxTokens bounds the reply to a single note, answer or review
+	defaultMaxTokens = 2048
+	// splitMaxTokens bounds the reply to a split, which holds a full note per idea
+	splitMaxTokens = 8192
+)
+
+// ErrResponseTruncated is returned when the model stopped at the token limit, so its reply is incomplete
+var ErrResponseTruncated = errors.New("LLM response truncated at the token limit")
+
 // Client represents the Claude API client
 type Client struct {
 	apiKey     string
@@ -56,15 +67,97 @@ Given a brief note or idea, you will:
 3. Expand the note into a markdown checklist with logical steps
 4. Keep steps actionable and specific
 5. Add brief context where helpful
+6. If related existing notes are provided and the note builds on one of them, add a line such as
+   "Builds on: Set Up Proxmox Cluster" below the title and skip steps that note already covers
 
 Respond ONLY with valid JSON in this exact format:
 {
   "title": "Clear Title Here",
   "category": "category_name",
-  "markdown": "# Title\n\n## Tasks\n- [ ] First step\n- [ ] Second step\n..."
+  "markdown": "# Title\n\n## Tasks\n- [ ] First step\n- [ ] Second step\n...",
+  "related": ["note_id of each related note this builds on"],
+  "tasks": [{"text": "First step", "due": "YYYY-MM-DD", "priority": "high", "effort": 1.5, "recurrence": "every week"}]
 }
 
 Keep the markdown concise but comprehensive. Each task should be completable in one sitting.
+Leave "related" empty when none of the provided notes is relevant.
+Add a "tasks" entry only for checklist items whose due date, priority (highest, high, medium, low, lowest),
+effort in hours or recurrence is clear from the note; omit fields you cannot tell and leave "tasks" empty otherwise.
+Due dates must be absolute (YYYY-MM-DD), counting from today's date given in the request.
+Do not include any text outside the JSON object.`
+
+// splitSystemPrompt for expanding input that may contain several unrelated ideas
+const splitSystemPrompt = `You are a productivity assistant that transforms quick notes into structured, actionable markdown todo lists.
+
+The input may contain one idea or several unrelated ideas (for example "fix garage door, try k3s on the pi, book dentist").
+Only split ideas that are genuinely distinct; steps or details of the same idea belong together.
+
+For each distinct idea, you will:
+1. Determine the most appropriate category from: homelab, coding, personal, learning, creative
+2. Create a clear, descriptive title
+3. Expand the idea into a markdown checklist with logical steps
+4. Keep steps actionable and specific
+5. Add brief context where helpful
+6. If related existing notes are provided and the idea builds on one of them, add a line such as
+   "Builds on: Set Up Proxmox Cluster" below the title and skip steps that note already covers
+
+Respond ONLY with valid JSON in this exact format:
+{
+  "notes": [
+    {
+      "original": "the part of the input this idea came from",
+      "title": "Clear Title Here",
+      "category": "category_name",
+      "markdown": "# Title\n\n## Tasks\n- [ ] First step\n- [ ] Second step\n...",
+      "related": ["note_id of each related note this idea builds on"],
+      "tasks": [{"text": "First step", "due": "YYYY-MM-DD", "priority": "high", "effort": 1.5, "recurrence": "every week"}]
+    }
+  ]
+}
+
+Keep the markdown concise but comprehensive. Each task should be completable in one sitting.
+Leave "related" empty when none of the provided notes is relevant.
+Add a "tasks" entry only for checklist items whose due date, priority (highest, high, medium, low, lowest),
+effort in hours or recurrence is clear from the note; omit fields you cannot tell and leave "tasks" empty otherwise.
+Due dates must be absolute (YYYY-MM-DD), counting from today's date given in the request.
+Do not include any text outside the JSON object.`
+
+// refineSystemPrompt for revising an already expanded note
+const refineSystemPrompt = `You are a productivity assistant that revises structured markdown todo lists.
+
+You will receive the user's original note, the current markdown of the expanded note and an instruction
+describing how to change it (for example "make it shorter" or "add a testing section").
+
+Apply the instruction to the current markdown while keeping everything the instruction does not touch.
+Earlier instructions in this conversation still apply unless the new instruction overrides them.
+Keep the category from: homelab, coding, personal, learning, creative (change it only if asked).
+
+Respond ONLY with valid JSON in this exact format:
+{
+  "title": "Clear Title Here",
+  "category": "category_name",
+  "markdown": "# Title\n\n## Tasks\n- [ ] First step\n- [ ] Second step\n...",
+  "tasks": [{"text": "First step", "due": "YYYY-MM-DD", "priority": "high", "effort": 1.5, "recurrence": "every week"}]
+}
+
+Add a "tasks" entry only for checklist items whose due date, priority (highest, high, medium, low, lowest),
+effort in hours or recurrence is clear from the note; omit fields you cannot tell and leave "tasks" empty otherwise.
+Due dates must be absolute (YYYY-MM-DD), counting from today's date given in the request.
+Do not include any text outside the JSON object.`
+
+// classifySystemPrompt for filing an existing note written outside IdeaForge
+const classifySystemPrompt = `You are a productivity assistant that files existing notes.
+
+Given a note from the user's Obsidian vault and its current title, you will:
+1. Determine the most appropriate category from: homelab, coding, personal, learning, creative
+2. Create a clear, descriptive title, keeping the current title if it already is one
+
+Respond ONLY with valid JSON in this exact format:
+{
+  "title": "Clear Title Here",
+  "category": "category_name"
+}
+
 Do not include any text outside the JSON object.`
 
 // anthropicRequest represents the API request structure
@@ -93,28 +186,135 @@ type anthropicResponse struct {
 	} `json:"error,omitempty"`
 }
 
-// ExpandNote takes a raw note and returns structured LLM response
-func (c *Client) ExpandNote(ctx context.Context, note string) (*models.LLMResponse, error) {
+// ExpandNote takes a raw note and returns structured LLM response.
+// Related existing notes are included as context so the expansion can build on them.
+func (c *Client) ExpandNote(ctx context.Context, note string, related []models.RelatedNote) (*models.LLMResponse, error) {
+	responseText, err := c.sendMessages(ctx, systemPrompt, []message{
+		{
+			Role:    "user",
+			Content: withToday(withRelatedContext(note, related)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	llmResponse, err := parseLLMResponse(responseText, "personal")
+	if err != nil {
+		return nil, err
+	}
+
+	llmResponse.Related = filterRelated(llmResponse.Related, related)
+	return llmResponse, nil
+}
+
+// ExpandIdeas expands a raw note that may contain several distinct ideas into one response per idea
+func (c *Client) ExpandIdeas(ctx context.Context, note string, related []models.RelatedNote) ([]models.LLMResponse, error) {
+	responseText, err := c.sendMessagesLimit(ctx, splitSystemPrompt, splitMaxTokens, []message{
+		{
+			Role:    "user",
+			Content: withToday(withRelatedContext(note, related)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var splitResponse struct {
+		Notes []models.LLMResponse `json:"notes"`
+	}
+	if err := json.Unmarshal([]byte(responseText), &splitResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM response as JSON: %w", err)
+	}
+
+	if len(splitResponse.Notes) == 0 {
+		return nil, fmt.Errorf("LLM response contained no notes")
+	}
+
+	for i := range splitResponse.Notes {
+		// Validate category
+		if !models.IsValidCategory(splitResponse.Notes[i].Category) {
+			splitResponse.Notes[i].Category = "personal" // Default fallback
+		}
+		splitResponse.Notes[i].Related = filterRelated(splitResponse.Notes[i].Related, related)
+		splitResponse.Notes[i].Tasks = models.SanitizeTaskDetails(splitResponse.Notes[i].Tasks)
+	}
+
+	return splitResponse.Notes, nil
+}
+
+// maxClassifyLength bounds how much of a note is sent for classification
+const maxClassifyLength = 8000
+
+// ClassifyNote picks a category and title for an existing note. Only Title and Category
+// of the response are set.
+func (c *Client) ClassifyNote(ctx context.Context, title, markdown string) (*models.LLMResponse, error) {
+	if len(markdown) > maxClassifyLength {
+		markdown = strings.ToValidUTF8(markdown[:maxClassifyLength], "")
+	}
+
+	responseText, err := c.sendMessages(ctx, classifySystemPrompt, []message{
+		{
+			Role:    "user",
+			Content: fmt.Sprintf("Current title: %s\n\n%s", title, markdown),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLLMResponse(responseText, "personal")
+}
+
+// RefineNote revises an existing note according to a follow-up instruction.
+// Earlier refinement turns are replayed so the model keeps their context. The note keeps its
+// category when the model answers with one that isn't valid.
+func (c *Client) RefineNote(ctx context.Context, original, markdown, category, instruction string, history []models.ConversationTurn) (*models.LLMResponse, error) {
+	messages := make([]message, 0, len(history)+1)
+	for _, turn := range history {
+		messages = append(messages, message{
+			Role:    turn.Role,
+			Content: turn.Content,
+		})
+	}
+
+	messages = append(messages, message{
+		Role: "user",
+		Content: withToday(fmt.Sprintf("Original note:\n%s\n\nCurrent markdown:\n%s\n\nInstruction: %s",
+			original, markdown, instruction)),
+	})
+
+	responseText, err := c.sendMessages(ctx, refineSystemPrompt, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLLMResponse(responseText, category)
+}
+
+// sendMessages sends a conversation to the API and returns the cleaned text of the reply
+func (c *Client) sendMessages(ctx context.Context, system string, messages []message) (string, error) {
+	return c.sendMessagesLimit(ctx, system, defaultMaxTokens, messages)
+}
+
+// sendMessagesLimit is sendMessages with a reply of up to maxTokens. A reply cut off at the
+// limit is an ErrResponseTruncated error rather than half a JSON document.
+func (c *Client) sendMessagesLimit(ctx context.Context, system string, maxTokens int, messages []message) (string, error) {
 	reqBody := anthropicRequest{
 		Model:     c.model,
-		MaxTokens: 2048,
-		System:    systemPrompt,
-		Messages: []message{
-			{
-				Role:    "user",
-				Content: note,
-			},
-		},
+		MaxTokens: maxTokens,
+		System:    system,
+		Messages:  messages,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", anthropicAPIURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -123,35 +323,43 @@ func (c *Client) ExpandNote(ctx context.Context, note string) (*models.LLMRespon
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var apiResp anthropicResponse
 	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
 	if apiResp.Error != nil {
-		return nil, fmt.Errorf("API error: %s", apiResp.Error.Message)
+		return "", fmt.Errorf("API error: %s", apiResp.Error.Message)
+	}
+
+	if apiResp.StopReason == "max_tokens" {
+		return "", fmt.Errorf("%w (%d tokens)", ErrResponseTruncated, maxTokens)
 	}
 
 	if len(apiResp.Content) == 0 || apiResp.Content[0].Type != "text" {
-		return nil, fmt.Errorf("unexpected response format")
+		return "", fmt.Errorf("unexpected response format")
 	}
 
 	// Clean the response text (LLMs sometimes wrap JSON in markdown code blocks)
-	responseText := cleanJSONResponse(apiResp.Content[0].Text)
+	return cleanJSONResponse(apiResp.Content[0].Text), nil
+}
 
+// parseLLMResponse decodes a note JSON object returned by the model; an invalid category is
+// replaced by the fallback
+func parseLLMResponse(responseText, fallbackCategory string) (*models.LLMResponse, error) {
 	var llmResponse models.LLMResponse
 	if err := json.Unmarshal([]byte(responseText), &llmResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse LLM response as JSON: %w", err)
@@ -159,12 +367,56 @@ func (c *Client) ExpandNote(ctx context.Context, note string) (*models.LLMRespon
 
 	// Validate category
 	if !models.IsValidCategory(llmResponse.Category) {
-		llmResponse.Category = "personal" // Default fallback
+		llmResponse.Category = fallbackCategory
 	}
 
+	llmResponse.Tasks = models.SanitizeTaskDetails(llmResponse.Tasks)
+
 	return &llmResponse, nil
 }
 
+// withRelatedContext prefixes the note with summaries of related existing notes
+func withRelatedContext(note string, related []models.RelatedNote) string {
+	if len(related) == 0 {
+		return note
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Related existing notes:\n")
+	for _, r := range related {
+		sb.WriteString(fmt.Sprintf("- [%s] %s (%s)\n", r.ID, r.Title, r.Category))
+		for _, task := range r.Tasks {
+			sb.WriteString(fmt.Sprintf("  - %s\n", task))
+		}
+	}
+	sb.WriteString("\nNew note:\n")
+	sb.WriteString(note)
+
+	return sb.String()
+}
+
+// withToday prefixes a request with today's date so relative due dates can be resolved
+func withToday(content string) string {
+	return fmt.Sprintf("Today's date: %s\n\n%s", time.Now().Format("2006-01-02"), content)
+}
+
+// filterRelated keeps only the IDs that were actually offered as context
+func filterRelated(ids []string, related []models.RelatedNote) []string {
+	valid := make(map[string]bool, len(related))
+	for _, r := range related {
+		valid[r.ID] = true
+	}
+
+	filtered := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if valid[id] {
+			filtered = append(filtered, id)
+			delete(valid, id) // drop duplicates
+		}
+	}
+	return filtered
+}
+
 // cleanJSONResponse strips markdown code blocks from LLM responses
 func cleanJSONResponse(text string) string {
 	text = strings.TrimSpace(text)
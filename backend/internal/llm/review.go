@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// reviewSystemPrompt for writing a GTD-style weekly review
+const reviewSystemPrompt = `You are a productivity coach writing a GTD-style weekly review of the user's idea notes.
+
+You will receive JSON describing the review period: notes created, notes completed, stale active notes
+that have not been touched for a while, and the number of open tasks per category.
+
+Write the review in markdown with exactly these sections:
+## Summary
+A short paragraph on what happened this week: what was captured, what got finished, where the focus was.
+
+## Stale Notes
+For each stale note, one bullet suggesting whether to continue, shrink, or archive it. Omit the section if there are none.
+
+## Suggested Next Actions
+3 to 7 concrete checklist items ("- [ ] ...") for next week, prioritizing categories with the most open tasks.
+
+Refer to notes by their exact title. Be concise and encouraging, never invent notes that are not in the data.
+Respond with the markdown only, without a top-level heading.`
+
+// WriteReview asks the LLM for a review summary with suggested next actions
+func (c *Client) WriteReview(ctx context.Context, data *models.ReviewData) (string, error) {
+	dataJSON, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal review data: %w", err)
+	}
+
+	return c.sendMessages(ctx, reviewSystemPrompt, []message{
+		{
+			Role:    "user",
+			Content: string(dataJSON),
+		},
+	})
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Attachment is a file uploaded to a note, such as a screenshot or a config file. The content
+// is stored once per SHA-256, however many notes or uploads share it.
+type Attachment struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	Filename  string    `json:"filename"` // name of the uploaded file
+	SHA256    string    `json:"sha256"`
+	MIMEType  string    `json:"mime_type"` // sniffed from the content, not taken from the upload
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -5,18 +5,38 @@ import "time"
 // NoteInput represents the raw input from the user
 type NoteInput struct {
 	Content string `json:"content" binding:"required"`
+	Split   *bool  `json:"split,omitempty"` // split multiple ideas into separate notes (default true)
+}
+
+// ShouldSplit reports whether the input may be split into several notes
+func (n *NoteInput) ShouldSplit() bool {
+	return n.Split == nil || *n.Split
 }
 
 // ProcessedNote represents a fully processed note with expanded content
 type ProcessedNote struct {
-	ID        string     `json:"id"`
-	Original  string     `json:"original"`
-	Title     string     `json:"title"`
-	Category  string     `json:"category"`
-	Markdown  string     `json:"markdown"`
-	Links     []Link     `json:"links"`
-	CreatedAt time.Time  `json:"created_at"`
-	SyncedAt  *time.Time `json:"synced_at,omitempty"`
+	ID          string        `json:"id"`
+	Original    string        `json:"original"`
+	Title       string        `json:"title"`
+	Category    string        `json:"category"`
+	Markdown    string        `json:"markdown"`
+	Links       []Link        `json:"links"`
+	RelatedIDs  []string      `json:"related_ids"`  // existing notes this note builds on
+	TaskDetails []TaskDetails `json:"task_details"` // due dates, priorities and effort of checklist items
+	Status      string        `json:"status"`       // active, completed, archived
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+	SyncedAt    *time.Time    `json:"synced_at,omitempty"`
+	VaultPath   string        `json:"vault_path,omitempty"` // file last written, relative to the vault root
+}
+
+// RelatedNote is a summary of an existing note given to the LLM as context
+type RelatedNote struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Category string   `json:"category"`
+	Tasks    []string `json:"tasks"`
 }
 
 // Link represents a resource link associated with a note
@@ -27,11 +47,103 @@ type Link struct {
 	Description string `json:"description,omitempty"`
 }
 
+// NoteUpdate represents a partial edit of a note; nil fields are left unchanged
+type NoteUpdate struct {
+	Title       *string        `json:"title"`
+	Category    *string        `json:"category"`
+	Markdown    *string        `json:"markdown"`
+	Links       *[]Link        `json:"links"`
+	Status      *string        `json:"status"`
+	TaskDetails *[]TaskDetails `json:"task_details"`
+}
+
+// NoteRevision is a snapshot of a note taken on every mutation
+type NoteRevision struct {
+	ID          int64         `json:"id"`
+	NoteID      string        `json:"note_id"`
+	Revision    int           `json:"revision"`
+	Title       string        `json:"title"`
+	Category    string        `json:"category"`
+	Markdown    string        `json:"markdown"`
+	Links       []Link        `json:"links"`
+	TaskDetails []TaskDetails `json:"task_details"`
+	Source      string        `json:"source"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// Revision sources describe what caused a note to change
+const (
+	RevisionSourceAPI        = "api"
+	RevisionSourceRegenerate = "llm-regenerate"
+	RevisionSourceImport     = "vault-import"
+	RevisionSourceRevert     = "revert"
+	RevisionSourceConflict   = "conflict-resolution"
+)
+
+// RefineInput represents a follow-up instruction for an existing note
+type RefineInput struct {
+	Instruction string `json:"instruction" binding:"required"`
+}
+
+// ConversationTurn is a single message in a note's refinement conversation
+type ConversationTurn struct {
+	Role      string    `json:"role"` // user, assistant
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SimilarNote is an existing note that closely matches another note or query
+type SimilarNote struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Category string  `json:"category"`
+	Score    float64 `json:"score"`
+}
+
+// DuplicateWarning flags a newly created note that is nearly identical to an existing one
+type DuplicateWarning struct {
+	NoteID      string      `json:"note_id"`
+	DuplicateOf SimilarNote `json:"duplicate_of"`
+}
+
+// MergeInput names the note another note should be merged into
+type MergeInput struct {
+	Into string `json:"into" binding:"required"`
+}
+
+// AskInput represents a question about the user's notes
+type AskInput struct {
+	Question  string `json:"question" binding:"required"`
+	SessionID string `json:"session_id,omitempty"` // continue an earlier conversation
+}
+
+// Citation references a note used to answer a question
+type Citation struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// AskResponse is the answer to a question about the user's notes
+type AskResponse struct {
+	SessionID string     `json:"session_id"`
+	Answer    string     `json:"answer"`
+	Citations []Citation `json:"citations"`
+}
+
 // LLMResponse represents the structured response from the LLM
 type LLMResponse struct {
-	Title    string `json:"title"`
-	Category string `json:"category"`
-	Markdown string `json:"markdown"`
+	Title    string        `json:"title"`
+	Category string        `json:"category"`
+	Markdown string        `json:"markdown"`
+	Original string        `json:"original,omitempty"` // part of the input this note came from, when split
+	Related  []string      `json:"related,omitempty"`  // IDs of existing notes this note builds on
+	Tasks    []TaskDetails `json:"tasks,omitempty"`    // optional metadata for checklist items
+}
+
+// LLMAnswer represents the structured answer to a question about notes
+type LLMAnswer struct {
+	Answer    string   `json:"answer"`
+	Citations []string `json:"citations"` // IDs of the notes the answer relies on
 }
 
 // Valid categories for notes
@@ -43,6 +155,43 @@ var ValidCategories = []string{
 	"creative",
 }
 
+// Note statuses
+const (
+	StatusActive    = "active"
+	StatusCompleted = "completed"
+	StatusArchived  = "archived"
+)
+
+// ValidStatuses lists the statuses a note can have
+var ValidStatuses = []string{
+	StatusActive,
+	StatusCompleted,
+	StatusArchived,
+}
+
+// IsValidStatus checks if a status is valid
+func IsValidStatus(status string) bool {
+	for _, s := range ValidStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStatus changes the status of a note, tracking when it was completed
+func (n *ProcessedNote) SetStatus(status string, now time.Time) {
+	if status == n.Status {
+		return
+	}
+	n.Status = status
+	if status == StatusCompleted {
+		n.CompletedAt = &now
+	} else {
+		n.CompletedAt = nil
+	}
+}
+
 // IsValidCategory checks if a category is valid
 func IsValidCategory(category string) bool {
 	for _, c := range ValidCategories {
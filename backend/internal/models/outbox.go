@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Vault operations queued in the outbox
+const (
+	VaultOpWrite  = "write"  // (re)write the note's file from the database
+	VaultOpMove   = "move"   // move the file from the previous location, then rewrite it
+	VaultOpDelete = "delete" // remove the file of a deleted note
+)
+
+// VaultOp is a pending vault operation that failed and is retried in the background
+type VaultOp struct {
+	ID            int64          `json:"id"`
+	NoteID        string         `json:"note_id"`
+	Op            string         `json:"op"`
+	Previous      *ProcessedNote `json:"-"` // note as it was before a move, or the deleted note
+	Attempts      int            `json:"attempts"`
+	LastError     string         `json:"last_error,omitempty"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// OutboxStats summarizes the pending vault operations
+type OutboxStats struct {
+	Pending int      `json:"pending"`
+	Oldest  *VaultOp `json:"oldest,omitempty"`
+}
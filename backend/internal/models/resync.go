@@ -0,0 +1,75 @@
+package models
+
+// Kinds of drift found by a vault resync
+const (
+	DriftMissing   = "missing"   // note has no file in the vault
+	DriftStale     = "stale"     // note changed after its file was last written
+	DriftMoved     = "moved"     // file is not where the note's title, category or route put it
+	DriftDuplicate = "duplicate" // another file carries the same note id
+	DriftOrphan    = "orphan"    // file id has no note in the database
+)
+
+// Outcomes of a resync action
+const (
+	ResyncPlanned     = "planned"     // dry run, nothing changed
+	ResyncWritten     = "written"     // file (re)written from the database
+	ResyncImported    = "imported"    // orphan file imported as a new note
+	ResyncQuarantined = "quarantined" // file moved to the quarantine folder
+	ResyncSkipped     = "skipped"     // left as is
+	ResyncFailed      = "failed"
+)
+
+// Orphan handling modes of a resync
+const (
+	OrphansKeep       = ""
+	OrphansImport     = "import"
+	OrphansQuarantine = "quarantine"
+)
+
+// ResyncOptions controls a reconciliation between the database and the vault
+type ResyncOptions struct {
+	DryRun  bool   `json:"dry_run"`
+	Orphans string `json:"orphans"` // "", "import" or "quarantine"
+}
+
+// ResyncAction is one difference between the database and the vault and what was done about it
+type ResyncAction struct {
+	Drift       string `json:"drift"`
+	NoteID      string `json:"note_id"`
+	Vault       string `json:"vault"`                  // vault folder of the file, or the note's if it has none
+	Path        string `json:"path,omitempty"`         // current file, relative to the vault root
+	TargetVault string `json:"target_vault,omitempty"` // vault folder the note is routed to, if it is another
+	Target      string `json:"target,omitempty"`       // expected file, relative to the vault root
+	Result      string `json:"result"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ResyncReport summarizes a vault resync
+type ResyncReport struct {
+	DryRun  bool           `json:"dry_run"`
+	Notes   int            `json:"notes"`
+	Files   int            `json:"files"`
+	Actions []ResyncAction `json:"actions"`
+	Summary map[string]int `json:"summary"` // number of actions per drift kind
+}
+
+// IsValidOrphanMode checks if an orphan handling mode is known
+func IsValidOrphanMode(mode string) bool {
+	return mode == OrphansKeep || mode == OrphansImport || mode == OrphansQuarantine
+}
+
+// Finish records the outcome of an action: result on success, failed with the error otherwise
+func (a *ResyncAction) Finish(err error, result string) {
+	if err != nil {
+		a.Result = ResyncFailed
+		a.Error = err.Error()
+		return
+	}
+	a.Result = result
+}
+
+// Add records an action and counts it in the summary
+func (r *ResyncReport) Add(action ResyncAction) {
+	r.Actions = append(r.Actions, action)
+	r.Summary[action.Drift]++
+}
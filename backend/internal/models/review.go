@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Review is a generated periodic review of notes
+type Review struct {
+	ID          string     `json:"id"` // ISO week, e.g. 2026-W42
+	PeriodStart time.Time  `json:"period_start"`
+	PeriodEnd   time.Time  `json:"period_end"`
+	Markdown    string     `json:"markdown"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SyncedAt    *time.Time `json:"synced_at,omitempty"`
+}
+
+// ReviewNote summarizes a note for a review
+type ReviewNote struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Category  string    `json:"category"`
+	OpenTasks int       `json:"open_tasks"`
+	DoneTasks int       `json:"done_tasks"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ReviewData is the activity gathered for a review period
+type ReviewData struct {
+	Week        string         `json:"week"`
+	PeriodStart time.Time      `json:"period_start"`
+	PeriodEnd   time.Time      `json:"period_end"`
+	Created     []ReviewNote   `json:"created"`
+	Completed   []ReviewNote   `json:"completed"`
+	Stale       []ReviewNote   `json:"stale"`
+	OpenTasks   map[string]int `json:"open_tasks"` // open task count per category
+}
+
+// ISOWeekID formats the ISO week of t, e.g. 2026-W42
+func ISOWeekID(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// WeekStart returns midnight of the Monday starting the ISO week of t
+func WeekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+	day := t.AddDate(0, 0, -offset)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// NewReviewNote summarizes a note and counts its tasks
+func NewReviewNote(note *ProcessedNote) ReviewNote {
+	summary := ReviewNote{
+		ID:        note.ID,
+		Title:     note.Title,
+		Category:  note.Category,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}
+	for _, task := range ExtractTasks(note.Markdown) {
+		if task.Done {
+			summary.DoneTasks++
+		} else {
+			summary.OpenTasks++
+		}
+	}
+	return summary
+}
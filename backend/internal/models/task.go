@@ -0,0 +1,124 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Task is a single checklist item parsed from note markdown
+type Task struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// taskPattern matches markdown checklist items such as "- [ ] step" or "* [x] step"
+var taskPattern = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.+)$`)
+
+// ExtractTasks returns the checklist items found in markdown, in order
+func ExtractTasks(markdown string) []Task {
+	var tasks []Task
+	for _, line := range strings.Split(markdown, "\n") {
+		match := taskPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		tasks = append(tasks, Task{
+			Text: strings.TrimSpace(match[2]),
+			Done: match[1] != " ",
+		})
+	}
+	return tasks
+}
+
+// TaskProgress counts the completed and total checklist items in markdown
+func TaskProgress(markdown string) (done, total int) {
+	for _, task := range ExtractTasks(markdown) {
+		total++
+		if task.Done {
+			done++
+		}
+	}
+	return done, total
+}
+
+// Task priorities, ordered from most to least urgent, as used by the Obsidian Tasks plugin
+var TaskPriorities = []string{"highest", "high", "medium", "low", "lowest"}
+
+// taskDateLayout is the format of task due dates
+const taskDateLayout = "2006-01-02"
+
+// TaskDetails holds optional planning metadata for a checklist item, matched to it by text
+type TaskDetails struct {
+	Text       string  `json:"text"`
+	Due        string  `json:"due,omitempty"`        // YYYY-MM-DD
+	Priority   string  `json:"priority,omitempty"`   // one of TaskPriorities
+	Effort     float64 `json:"effort,omitempty"`     // estimated hours
+	Recurrence string  `json:"recurrence,omitempty"` // e.g. "every week"
+}
+
+// PriorityRank returns the position of a priority in TaskPriorities, or -1 if it is unknown
+func PriorityRank(priority string) int {
+	for i, p := range TaskPriorities {
+		if p == priority {
+			return i
+		}
+	}
+	return -1
+}
+
+// Validate reports the first invalid field of the task details
+func (d TaskDetails) Validate() error {
+	if strings.TrimSpace(d.Text) == "" {
+		return fmt.Errorf("task text is required")
+	}
+	if d.Due != "" {
+		if _, err := time.Parse(taskDateLayout, d.Due); err != nil {
+			return fmt.Errorf("invalid due date %q, expected YYYY-MM-DD", d.Due)
+		}
+	}
+	if d.Priority != "" && PriorityRank(d.Priority) == -1 {
+		return fmt.Errorf("invalid priority %q", d.Priority)
+	}
+	if d.Effort < 0 {
+		return fmt.Errorf("effort cannot be negative")
+	}
+	return nil
+}
+
+// SanitizeTaskDetails drops invalid fields from task details produced by the LLM,
+// and entries that carry no metadata at all
+func SanitizeTaskDetails(details []TaskDetails) []TaskDetails {
+	cleaned := make([]TaskDetails, 0, len(details))
+	for _, d := range details {
+		d.Text = strings.TrimSpace(d.Text)
+		d.Priority = strings.ToLower(strings.TrimSpace(d.Priority))
+		d.Recurrence = strings.TrimSpace(d.Recurrence)
+		if _, err := time.Parse(taskDateLayout, d.Due); err != nil {
+			d.Due = ""
+		}
+		if PriorityRank(d.Priority) == -1 {
+			d.Priority = ""
+		}
+		if d.Effort < 0 {
+			d.Effort = 0
+		}
+		if d.Text == "" || (d.Due == "" && d.Priority == "" && d.Effort == 0 && d.Recurrence == "") {
+			continue
+		}
+		cleaned = append(cleaned, d)
+	}
+	return cleaned
+}
+
+// FindTaskDetails returns the details recorded for a checklist item, or nil if there are none
+func FindTaskDetails(details []TaskDetails, text string) *TaskDetails {
+	key := strings.ToLower(strings.TrimSpace(text))
+	for i := range details {
+		if strings.ToLower(strings.TrimSpace(details[i].Text)) == key {
+			return &details[i]
+		}
+	}
+	return nil
+}
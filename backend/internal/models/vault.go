@@ -0,0 +1,18 @@
+package models
+
+// VaultHealth reports whether the vault can be written and whether written files end up
+// with the owner Syncthing expects. Owners are rendered as uid:gid.
+type VaultHealth struct {
+	Name          string `json:"name"`
+	Path          string `json:"path"`
+	Folder        string `json:"folder"`
+	Writable      bool   `json:"writable"`
+	ProbeError    string `json:"probe_error,omitempty"`
+	FileMode      string `json:"file_mode"`
+	DirMode       string `json:"dir_mode"`
+	Chown         bool   `json:"chown"` // written files are handed to PUID:PGID
+	ExpectedOwner string `json:"expected_owner,omitempty"`
+	FileOwner     string `json:"file_owner,omitempty"`   // owner of a freshly written file
+	FolderOwner   string `json:"folder_owner,omitempty"` // owner of the IdeaForge folder
+	OwnerMismatch bool   `json:"owner_mismatch"`
+}
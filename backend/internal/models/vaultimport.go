@@ -0,0 +1,53 @@
+package models
+
+// Outcomes of importing a vault file
+const (
+	ImportPlanned  = "planned"  // dry run, nothing changed
+	ImportImported = "imported" // note created and the file adopted
+	ImportSkipped  = "skipped"  // file already belongs to a note
+	ImportFailed   = "failed"
+)
+
+// ImportOptions controls an import of markdown files written outside IdeaForge
+type ImportOptions struct {
+	Vault    string `json:"vault"`    // vault folder name as shown in /health, "" for the default vault
+	Folder   string `json:"folder"`   // relative to the vault root, "" for the whole vault
+	Classify bool   `json:"classify"` // ask the LLM for the title and category
+	DryRun   bool   `json:"dry_run"`
+}
+
+// ImportAction is one file considered by an import and what was done with it
+type ImportAction struct {
+	Path     string `json:"path"` // relative to the vault root
+	NoteID   string `json:"note_id,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Category string `json:"category,omitempty"`
+	Result   string `json:"result"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ImportReport summarizes a vault import
+type ImportReport struct {
+	Vault   string         `json:"vault"`
+	Folder  string         `json:"folder"`
+	DryRun  bool           `json:"dry_run"`
+	Files   int            `json:"files"`
+	Actions []ImportAction `json:"actions"`
+	Summary map[string]int `json:"summary"` // number of actions per result
+}
+
+// Finish records the outcome of an action: result on success, failed with the error otherwise
+func (a *ImportAction) Finish(err error, result string) {
+	if err != nil {
+		a.Result = ImportFailed
+		a.Error = err.Error()
+		return
+	}
+	a.Result = result
+}
+
+// Add records an action and counts it in the summary
+func (r *ImportReport) Add(action ImportAction) {
+	r.Actions = append(r.Actions, action)
+	r.Summary[action.Result]++
+}
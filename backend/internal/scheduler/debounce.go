@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// Debouncer coalesces bursts of triggers into a single call that runs once things have been quiet for a delay
+type Debouncer struct {
+	mu    sync.Mutex
+	delay time.Duration
+	fn    func()
+	timer *time.Timer
+}
+
+// NewDebouncer creates a debouncer that calls fn after delay without new triggers
+func NewDebouncer(delay time.Duration, fn func()) *Debouncer {
+	return &Debouncer{
+		delay: delay,
+		fn:    fn,
+	}
+}
+
+// Trigger schedules a call, postponing any call that is already pending
+func (d *Debouncer) Trigger() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timer = time.AfterFunc(d.delay, d.fn)
+}
+
+// Flush runs a pending call immediately, if there is one
+func (d *Debouncer) Flush() {
+	d.mu.Lock()
+	pending := d.timer != nil && d.timer.Stop()
+	d.timer = nil
+	d.mu.Unlock()
+
+	if pending {
+		d.fn()
+	}
+}
@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// defaultJobTimeout bounds a single job run
+const defaultJobTimeout = 10 * time.Minute
+
+// Job is a unit of scheduled work
+type Job func(ctx context.Context) error
+
+// Scheduler runs named jobs on cron schedules
+type Scheduler struct {
+	cron *cron.Cron
+}
+
+// New creates a scheduler using the local time zone.
+// Overlapping runs of the same job are skipped rather than queued.
+func New() *Scheduler {
+	return &Scheduler{
+		cron: cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger))),
+	}
+}
+
+// Add registers a job under a standard five-field cron spec (or a descriptor such as "@weekly")
+func (s *Scheduler) Add(name, spec string, job Job) error {
+	_, err := s.cron.AddFunc(spec, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultJobTimeout)
+		defer cancel()
+
+		start := time.Now()
+		log.Printf("Scheduled job %s started", name)
+		if err := job(ctx); err != nil {
+			log.Printf("Scheduled job %s failed: %v", name, err)
+			return
+		}
+		log.Printf("Scheduled job %s finished in %s", name, time.Since(start).Round(time.Millisecond))
+	})
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for job %s: %w", spec, name, err)
+	}
+	return nil
+}
+
+// Every registers a job that runs at a fixed interval, such as a background retry loop.
+// Runs are not logged, only failures.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.cron.Schedule(cron.Every(interval), cron.FuncJob(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultJobTimeout)
+		defer cancel()
+
+		if err := job(ctx); err != nil {
+			log.Printf("Scheduled job %s failed: %v", name, err)
+		}
+	}))
+}
+
+// Start begins running jobs in the background
+func (s *Scheduler) Start() {
+	s.cron.Start()
+}
+
+// Stop halts the scheduler and waits for running jobs to finish
+func (s *Scheduler) Stop() {
+	<-s.cron.Stop().Done()
+}
@@ -0,0 +1,201 @@
+package similarity
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Match is a document similar to a query, with its cosine similarity score (0-1)
+type Match struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// Index is an in-memory TF-IDF index over note text.
+// It runs entirely locally and is safe for concurrent use.
+type Index struct {
+	mu   sync.RWMutex
+	docs map[string]map[string]int // document ID -> term counts
+	df   map[string]int            // term -> number of documents containing it
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		docs: make(map[string]map[string]int),
+		df:   make(map[string]int),
+	}
+}
+
+// Add indexes a document, replacing any previous version with the same ID
+func (i *Index) Add(id, text string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+
+	counts := make(map[string]int)
+	for _, term := range Tokenize(text) {
+		counts[term]++
+	}
+	for term := range counts {
+		i.df[term]++
+	}
+	i.docs[id] = counts
+}
+
+// Remove drops a document from the index
+func (i *Index) Remove(id string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+}
+
+// remove drops a document; the caller must hold the write lock
+func (i *Index) remove(id string) {
+	counts, ok := i.docs[id]
+	if !ok {
+		return
+	}
+	for term := range counts {
+		i.df[term]--
+		if i.df[term] <= 0 {
+			delete(i.df, term)
+		}
+	}
+	delete(i.docs, id)
+}
+
+// Len returns the number of indexed documents
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return len(i.docs)
+}
+
+// Query returns up to limit documents most similar to the text, best first.
+// Documents with no terms in common are never returned.
+func (i *Index) Query(text string, limit int) []Match {
+	counts := make(map[string]int)
+	for _, term := range Tokenize(text) {
+		counts[term]++
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.rank(counts, "", limit)
+}
+
+// Related returns up to limit documents most similar to an indexed document, excluding itself
+func (i *Index) Related(id string, limit int) []Match {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	counts, ok := i.docs[id]
+	if !ok {
+		return nil
+	}
+	return i.rank(counts, id, limit)
+}
+
+// rank scores every document against the query term counts; the caller must hold the read lock
+func (i *Index) rank(query map[string]int, exclude string, limit int) []Match {
+	queryVec := i.weigh(query)
+	if len(queryVec) == 0 {
+		return nil
+	}
+
+	var matches []Match
+	for id, counts := range i.docs {
+		if id == exclude {
+			continue
+		}
+		if score := cosine(queryVec, i.weigh(counts)); score > 0 {
+			matches = append(matches, Match{ID: id, Score: score})
+		}
+	}
+
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return matches[a].ID < matches[b].ID
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// weigh converts term counts into a TF-IDF vector using the current document frequencies
+func (i *Index) weigh(counts map[string]int) map[string]float64 {
+	n := float64(len(i.docs))
+	vec := make(map[string]float64, len(counts))
+	for term, count := range counts {
+		df := i.df[term]
+		if df == 0 {
+			continue // term unknown to the corpus cannot match anything
+		}
+		idf := math.Log(1 + n/float64(df))
+		vec[term] = (1 + math.Log(float64(count))) * idf
+	}
+	return vec
+}
+
+// cosine returns the cosine similarity of two sparse vectors
+func cosine(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for term, wa := range a {
+		normA += wa * wa
+		if wb, ok := b[term]; ok {
+			dot += wa * wb
+		}
+	}
+	for _, wb := range b {
+		normB += wb * wb
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// stopWords are common words ignored when indexing and searching
+var stopWords = map[string]bool{
+	"an": true, "as": true, "at": true, "be": true, "by": true, "do": true, "if": true,
+	"in": true, "is": true, "it": true, "me": true, "my": true, "no": true, "of": true,
+	"on": true, "or": true, "so": true, "to": true, "up": true, "we": true,
+	"the": true, "and": true, "for": true, "with": true, "from": true, "into": true,
+	"that": true, "this": true, "then": true, "than": true, "have": true, "are": true,
+	"you": true, "your": true, "was": true, "will": true, "can": true, "not": true,
+	"all": true, "any": true, "each": true, "use": true, "using": true, "its": true,
+	"want": true, "need": true, "some": true, "about": true, "what": true, "when": true,
+	"how": true, "try": true, "get": true, "set": true, "make": true, "out": true, "our": true,
+}
+
+// Tokenize splits text into lowercase terms with a light plural stemming, skipping single
+// characters and stop words. Keyword search uses it too, so both split text the same way.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if len([]rune(word)) < 2 || stopWords[word] {
+			continue
+		}
+		if len(word) > 4 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			word = strings.TrimSuffix(word, "s")
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
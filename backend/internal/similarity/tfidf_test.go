@@ -0,0 +1,42 @@
+package similarity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Set up the Go backups for my NAS", []string{"go", "backup", "nas"}},
+		{"Classes, grass & bus stops!", []string{"classe", "grass", "bus", "stop"}},
+		{"Déjà vu: Grüße aus Köln", []string{"déjà", "vu", "grüße", "aus", "köln"}},
+		{"a b c", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIndexRelated(t *testing.T) {
+	index := NewIndex()
+	index.Add("rack", "Rebuild the homelab rack with new cabling")
+	index.Add("cabling", "Replace the homelab cabling")
+	index.Add("bread", "Bake sourdough bread")
+
+	related := index.Related("rack", 5)
+	if len(related) != 1 || related[0].ID != "cabling" {
+		t.Errorf("Related(rack) = %+v, want only cabling", related)
+	}
+	if matches := index.Query("homelab rack", 1); len(matches) != 1 || matches[0].ID != "rack" {
+		t.Errorf("Query(homelab rack) = %+v, want rack", matches)
+	}
+	if index.Related("missing", 5) != nil {
+		t.Error("Related of an unindexed document returned matches")
+	}
+}
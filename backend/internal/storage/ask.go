@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// ListAskMessages returns the messages of a question-answer session, oldest first
+func (d *Database) ListAskMessages(sessionID string) ([]models.ConversationTurn, error) {
+	rows, err := d.db.Query(`
+		SELECT role, content, created_at
+		FROM ask_messages WHERE session_id = ?
+		ORDER BY id ASC
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ask session: %w", err)
+	}
+	defer rows.Close()
+
+	turns := make([]models.ConversationTurn, 0)
+	for rows.Next() {
+		var turn models.ConversationTurn
+		if err := rows.Scan(&turn.Role, &turn.Content, &turn.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ask message: %w", err)
+		}
+		turns = append(turns, turn)
+	}
+
+	return turns, rows.Err()
+}
+
+// AppendAskMessages stores new messages of a question-answer session
+func (d *Database) AppendAskMessages(sessionID string, turns ...models.ConversationTurn) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, turn := range turns {
+		_, err := tx.Exec(`
+			INSERT INTO ask_messages (session_id, role, content, created_at)
+			VALUES (?, ?, ?, ?)
+		`, sessionID, turn.Role, turn.Content, turn.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert ask message: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,336 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+const (
+	// attachmentFolder holds the vault copies of attachments, below the IdeaForge folder
+	attachmentFolder = "_attachments"
+	// defaultAttachmentMaxMB is the upload size limit unless ATTACHMENT_MAX_MB sets another
+	defaultAttachmentMaxMB = 10
+)
+
+// attachmentTypes are the accepted content types, sniffed from the first bytes of an upload,
+// with the extension given to files uploaded without one. Anything a browser might render as
+// a page, such as HTML or SVG, is rejected.
+var attachmentTypes = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/bmp":       ".bmp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+}
+
+var (
+	// ErrAttachmentTooLarge is returned for an upload over the size limit
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	// ErrAttachmentType is returned for an upload whose content type is not accepted
+	ErrAttachmentType = errors.New("attachment type is not supported")
+)
+
+// AttachmentStore keeps attachment content in a directory addressed by SHA-256, so a file
+// uploaded twice is stored once
+type AttachmentStore struct {
+	dir     string
+	maxSize int64
+}
+
+// NewAttachmentStore opens ATTACHMENTS_PATH, by default an attachments folder next to the database
+func NewAttachmentStore() (*AttachmentStore, error) {
+	dir := os.Getenv("ATTACHMENTS_PATH")
+	if dir == "" {
+		dbPath := os.Getenv("DATABASE_PATH")
+		if dbPath == "" {
+			dbPath = "./data/ideaforge.db"
+		}
+		dir = filepath.Join(filepath.Dir(dbPath), "attachments")
+	}
+
+	maxMB := defaultAttachmentMaxMB
+	if value := os.Getenv("ATTACHMENT_MAX_MB"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("ATTACHMENT_MAX_MB must be a positive number of megabytes, got %q", value)
+		}
+		maxMB = parsed
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+
+	return &AttachmentStore{dir: dir, maxSize: int64(maxMB) << 20}, nil
+}
+
+// MaxSize is the size limit of a single attachment, in bytes
+func (s *AttachmentStore) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Save stores uploaded content and returns its SHA-256, size and sniffed content type.
+// The content is streamed to a temp file, so a rejected upload never reaches the store.
+func (s *AttachmentStore) Save(r io.Reader) (sum string, size int64, mimeType string, err error) {
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return "", 0, "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	hash := sha256.New()
+	head := &headBuffer{limit: 512}
+	size, err = io.Copy(io.MultiWriter(tmp, hash, head), io.LimitReader(r, s.maxSize+1))
+	if maxBytes := new(http.MaxBytesError); errors.As(err, &maxBytes) {
+		// The request as a whole went over its limit partway through this file
+		return "", 0, "", fmt.Errorf("%w: the request is over %d bytes", ErrAttachmentTooLarge, maxBytes.Limit)
+	}
+	if err != nil {
+		return "", 0, "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	if size > s.maxSize {
+		return "", 0, "", fmt.Errorf("%w: the limit is %d MB", ErrAttachmentTooLarge, s.maxSize>>20)
+	}
+
+	mimeType, _, _ = mime.ParseMediaType(http.DetectContentType(head.data))
+	if _, ok := attachmentTypes[mimeType]; !ok || size == 0 {
+		return "", 0, "", fmt.Errorf("%w: %s", ErrAttachmentType, mimeType)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return "", 0, "", fmt.Errorf("failed to sync upload: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, "", fmt.Errorf("failed to close upload: %w", err)
+	}
+
+	sum = hex.EncodeToString(hash.Sum(nil))
+	target := s.Path(sum)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", 0, "", fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", 0, "", fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	return sum, size, mimeType, nil
+}
+
+// Path is the stored file of the content with a SHA-256, in a subdirectory per first byte
+func (s *AttachmentStore) Path(sum string) string {
+	return filepath.Join(s.dir, sum[:2], sum)
+}
+
+// Remove deletes stored content; content that is already gone is not an error
+func (s *AttachmentStore) Remove(sum string) error {
+	if err := os.Remove(s.Path(sum)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return nil
+}
+
+// headBuffer keeps the first bytes written to it, for content sniffing
+type headBuffer struct {
+	data  []byte
+	limit int
+}
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.data); room > 0 {
+		b.data = append(b.data, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// AttachmentFilename cleans the name of an uploaded file, adding the extension of its
+// content type when it has none
+func AttachmentFilename(name, mimeType string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/:*?"<>|[]#^`, r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.TrimLeft(name, ".")
+
+	if name == "" {
+		name = "attachment"
+	}
+	if filepath.Ext(name) == "" {
+		name += attachmentTypes[mimeType]
+	}
+	return name
+}
+
+// attachmentVaultName is the name of an attachment's copy in the vault: the slug of the upload's
+// name with a short content hash, so different files uploaded under one name don't collide
+func attachmentVaultName(a *models.Attachment) string {
+	ext := strings.ToLower(filepath.Ext(a.Filename))
+	stem := slugify(strings.TrimSuffix(a.Filename, filepath.Ext(a.Filename)))
+	if stem == "" {
+		stem = "attachment"
+	}
+	return stem + "-" + a.SHA256[:8] + ext
+}
+
+// CreateAttachment stores the record of an uploaded attachment
+func (d *Database) CreateAttachment(a *models.Attachment) error {
+	_, err := d.db.Exec(`
+		INSERT INTO attachments (id, note_id, filename, sha256, mime_type, size, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, a.ID, a.NoteID, a.Filename, a.SHA256, a.MIMEType, a.Size, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert attachment: %w", err)
+	}
+	return nil
+}
+
+// ListAttachments returns the attachments of a note, oldest first
+func (d *Database) ListAttachments(noteID string) ([]models.Attachment, error) {
+	return d.queryAttachments("note_id = ?", noteID)
+}
+
+// GetAttachment returns an attachment of a note, or nil if the note has no such attachment
+func (d *Database) GetAttachment(noteID, id string) (*models.Attachment, error) {
+	var a models.Attachment
+	err := d.db.QueryRow(`
+		SELECT id, note_id, filename, sha256, mime_type, size, created_at
+		FROM attachments
+		WHERE id = ? AND note_id = ?
+	`, id, noteID).Scan(&a.ID, &a.NoteID, &a.Filename, &a.SHA256, &a.MIMEType, &a.Size, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// DeleteAttachment removes the record of an attachment
+func (d *Database) DeleteAttachment(id string) error {
+	if _, err := d.db.Exec("DELETE FROM attachments WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return nil
+}
+
+// DeleteNoteAttachments removes the records of all attachments of a note and returns them
+func (d *Database) DeleteNoteAttachments(noteID string) ([]models.Attachment, error) {
+	attachments, err := d.ListAttachments(noteID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.db.Exec("DELETE FROM attachments WHERE note_id = ?", noteID); err != nil {
+		return nil, fmt.Errorf("failed to delete attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// MoveAttachments hands the attachments of one note to another, as when notes are merged
+func (d *Database) MoveAttachments(fromID, toID string) error {
+	if _, err := d.db.Exec("UPDATE attachments SET note_id = ? WHERE note_id = ?", toID, fromID); err != nil {
+		return fmt.Errorf("failed to move attachments: %w", err)
+	}
+	return nil
+}
+
+// ContentAttachments returns the attachments of any note with some content
+func (d *Database) ContentAttachments(sum string) ([]models.Attachment, error) {
+	return d.queryAttachments("sha256 = ?", sum)
+}
+
+// queryAttachments returns the attachments matching a condition, oldest first
+func (d *Database) queryAttachments(where string, args ...any) ([]models.Attachment, error) {
+	rows, err := d.db.Query(`
+		SELECT id, note_id, filename, sha256, mime_type, size, created_at
+		FROM attachments
+		WHERE `+where+`
+		ORDER BY created_at, id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := make([]models.Attachment, 0)
+	for rows.Next() {
+		var a models.Attachment
+		if err := rows.Scan(&a.ID, &a.NoteID, &a.Filename, &a.SHA256, &a.MIMEType, &a.Size, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// AttachmentsFunc returns the attachments of a note, embedded at the end of its file
+type AttachmentsFunc func(note *models.ProcessedNote) []models.Attachment
+
+// SetAttachments sets where attachment content is stored and how the attachments of a note
+// are looked up when its file is written
+func (w *ObsidianWriter) SetAttachments(store *AttachmentStore, fn AttachmentsFunc) {
+	w.attachmentStore, w.attachments = store, fn
+}
+
+// attachmentPath is the vault path of an attachment's copy
+func (w *ObsidianWriter) attachmentPath(a *models.Attachment) string {
+	return filepath.Join(w.folderName, attachmentFolder, attachmentVaultName(a))
+}
+
+// writeAttachments copies the attachments of a note that aren't in the vault yet. Copies are
+// named by content hash, so an existing copy is never out of date.
+func (w *ObsidianWriter) writeAttachments(attachments []models.Attachment) error {
+	for _, a := range attachments {
+		full, err := w.resolve(w.attachmentPath(&a))
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(full); err == nil {
+			continue
+		}
+
+		data, err := os.ReadFile(w.attachmentStore.Path(a.SHA256))
+		if err != nil {
+			return fmt.Errorf("failed to read attachment %s: %w", a.ID, err)
+		}
+		if err := w.mkdirAll(filepath.Dir(full)); err != nil {
+			return fmt.Errorf("failed to create attachments folder: %w", err)
+		}
+		if err := w.writeFile(full, data); err != nil {
+			return fmt.Errorf("failed to write attachment: %w", err)
+		}
+	}
+	return nil
+}
+
+// RemoveAttachment deletes the vault copy of a removed attachment, unless one of the remaining
+// attachments with the same content shares it
+func (w *ObsidianWriter) RemoveAttachment(a *models.Attachment, remaining []models.Attachment) error {
+	for _, other := range remaining {
+		if attachmentVaultName(&other) == attachmentVaultName(a) {
+			return nil
+		}
+	}
+	return w.RemoveFile(w.attachmentPath(a))
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestAttachmentStore opens a store in a temp dir with a size limit in megabytes
+func newTestAttachmentStore(t *testing.T, maxMB string) *AttachmentStore {
+	t.Helper()
+	t.Setenv("ATTACHMENTS_PATH", t.TempDir())
+	t.Setenv("ATTACHMENT_MAX_MB", maxMB)
+	store, err := NewAttachmentStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSaveRequestOverLimit(t *testing.T) {
+	store := newTestAttachmentStore(t, "1")
+
+	// The request limit is hit partway through a file that is within the attachment limit
+	body := io.NopCloser(bytes.NewReader(bytes.Repeat([]byte("text "), 1000)))
+	reader := http.MaxBytesReader(httptest.NewRecorder(), body, 100)
+
+	if _, _, _, err := store.Save(reader); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Errorf("Save over the request limit = %v, want ErrAttachmentTooLarge", err)
+	}
+
+	// Likewise when the file is read from a multipart upload
+	var upload bytes.Buffer
+	writer := multipart.NewWriter(&upload)
+	file, _ := writer.CreateFormFile("file", "notes.txt")
+	file.Write(bytes.Repeat([]byte("text "), 1000))
+	writer.Close()
+
+	limited := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(&upload), 1000)
+	part, err := multipart.NewReader(limited, writer.Boundary()).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := store.Save(part); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Errorf("Save of a multipart file over the request limit = %v, want ErrAttachmentTooLarge", err)
+	}
+}
+
+func TestSaveSizeLimit(t *testing.T) {
+	store := newTestAttachmentStore(t, "1")
+
+	limit := bytes.Repeat([]byte("a"), 1<<20)
+	sum, size, mimeType, err := store.Save(bytes.NewReader(limit))
+	if err != nil {
+		t.Fatalf("Save at the limit: %v", err)
+	}
+	if size != 1<<20 || mimeType != "text/plain" {
+		t.Errorf("Save at the limit = %d bytes of %s, want %d bytes of text/plain", size, mimeType, 1<<20)
+	}
+	if data, err := os.ReadFile(store.Path(sum)); err != nil || !bytes.Equal(data, limit) {
+		t.Errorf("stored content differs from the upload (%v)", err)
+	}
+
+	over := append(limit, 'a')
+	if _, _, _, err := store.Save(bytes.NewReader(over)); !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Errorf("Save over the limit = %v, want ErrAttachmentTooLarge", err)
+	}
+	assertNoUploads(t, store)
+}
+
+func TestSaveContentTypes(t *testing.T) {
+	store := newTestAttachmentStore(t, "1")
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	tests := []struct {
+		name     string
+		content  []byte
+		wantType string // empty when rejected
+	}{
+		{"png", png, "image/png"},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"), "image/jpeg"},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), "image/gif"},
+		{"pdf", []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n"), "application/pdf"},
+		{"text", []byte("Plain notes\nwith two lines\n"), "text/plain"},
+		{"html", []byte("<!DOCTYPE html><html><body>hi</body></html>"), ""},
+		{"html with leading space", []byte("  \n<script>alert(1)</script>"), ""},
+		{"svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`), ""},
+		{"zip", []byte("PK\x03\x04\x14\x00\x00\x00"), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		_, _, mimeType, err := store.Save(bytes.NewReader(tt.content))
+		switch {
+		case tt.wantType == "" && !errors.Is(err, ErrAttachmentType):
+			t.Errorf("%s: Save = %q, %v, want ErrAttachmentType", tt.name, mimeType, err)
+		case tt.wantType != "" && (err != nil || mimeType != tt.wantType):
+			t.Errorf("%s: Save = %q, %v, want %s", tt.name, mimeType, err, tt.wantType)
+		}
+	}
+	assertNoUploads(t, store)
+}
+
+// assertNoUploads checks that rejected uploads left no temp files behind
+func assertNoUploads(t *testing.T, store *AttachmentStore) {
+	t.Helper()
+	leftover, _ := filepath.Glob(filepath.Join(store.dir, ".upload-*"))
+	if len(leftover) > 0 {
+		t.Errorf("temp files left behind: %v", leftover)
+	}
+}
@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+const (
+	// categoryCanvasName is the per-category canvas, next to the category index
+	categoryCanvasName = "_board"
+	// tagCanvasFolder holds the canvases of the configured tags, below the IdeaForge folder
+	tagCanvasFolder = "_boards"
+)
+
+// Canvas layout, in canvas pixels: one group per status laid out as columns of note cards
+const (
+	canvasCardWidth   = 400
+	canvasCardHeight  = 240
+	canvasCardGap     = 40
+	canvasGroupMargin = 40
+	canvasColumnGap   = 120
+)
+
+// canvasStatusColors are the preset colors of the status groups; archived notes stay uncolored
+var canvasStatusColors = map[string]string{
+	models.StatusActive:    "5",
+	models.StatusCompleted: "4",
+}
+
+var (
+	// hashtagPattern matches inline tags such as #homelab-rebuild, but not headings
+	hashtagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+	// wikilinkPattern matches a wikilink, capturing its target without heading or alias
+	wikilinkPattern = regexp.MustCompile(`\[\[([^\]|#]+)(?:#[^\]|]*)?(?:\|[^\]]*)?\]\]`)
+)
+
+// canvasConfig selects which canvases are generated
+type canvasConfig struct {
+	tags []string // lowercase tags that get a canvas besides the categories
+}
+
+// loadCanvasConfig reads OBSIDIAN_CANVAS_TAGS, a comma-separated list of tags such as "homelab-rebuild"
+func loadCanvasConfig() *canvasConfig {
+	cfg := &canvasConfig{}
+	for _, tag := range strings.Split(os.Getenv("OBSIDIAN_CANVAS_TAGS"), ",") {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag != "" && !slices.Contains(cfg.tags, tag) {
+			cfg.tags = append(cfg.tags, tag)
+		}
+	}
+	return cfg
+}
+
+// jsonCanvas is a document in the JSON Canvas format used by Obsidian (jsoncanvas.org)
+type jsonCanvas struct {
+	Nodes []canvasNode `json:"nodes"`
+	Edges []canvasEdge `json:"edges"`
+}
+
+type canvasNode struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"` // file or group
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Color  string `json:"color,omitempty"`
+	File   string `json:"file,omitempty"`
+	Label  string `json:"label,omitempty"`
+}
+
+type canvasEdge struct {
+	ID       string `json:"id"`
+	FromNode string `json:"fromNode"`
+	FromSide string `json:"fromSide,omitempty"`
+	FromEnd  string `json:"fromEnd,omitempty"`
+	ToNode   string `json:"toNode"`
+	ToSide   string `json:"toSide,omitempty"`
+	ToEnd    string `json:"toEnd,omitempty"`
+	Label    string `json:"label,omitempty"`
+}
+
+// WriteCanvases regenerates the canvas of every category and configured tag. Like the index
+// notes, canvases are rebuilt from the database, so cards moved by hand return to their column.
+func (w *ObsidianWriter) WriteCanvases(notes []models.ProcessedNote) error {
+	if w.canvas == nil {
+		return nil
+	}
+
+	byCategory := make(map[string][]models.ProcessedNote)
+	for _, note := range notes {
+		byCategory[note.Category] = append(byCategory[note.Category], note)
+	}
+
+	for _, category := range indexCategories(byCategory) {
+		canvasPath := filepath.Join(w.vaultPath, w.folderName, category, categoryCanvasName+".canvas")
+		if err := w.writeCanvas(canvasPath, byCategory[category]); err != nil {
+			return err
+		}
+	}
+
+	for _, tag := range w.canvas.tags {
+		var tagged []models.ProcessedNote
+		for _, note := range notes {
+			if slices.Contains(noteHashtags(note.Markdown), tag) {
+				tagged = append(tagged, note)
+			}
+		}
+
+		canvasPath := filepath.Join(w.vaultPath, w.folderName, tagCanvasFolder, slugify(tag)+".canvas")
+		if err := w.writeCanvas(canvasPath, tagged); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeCanvas writes the canvas of a set of notes. No canvas is created for an empty set,
+// but an existing one is kept up to date.
+func (w *ObsidianWriter) writeCanvas(canvasPath string, notes []models.ProcessedNote) error {
+	if len(notes) == 0 {
+		if _, err := os.Stat(canvasPath); err != nil {
+			return nil
+		}
+	}
+
+	content, err := w.generateCanvas(notes)
+	if err != nil {
+		return err
+	}
+
+	if err := w.mkdirAll(filepath.Dir(canvasPath)); err != nil {
+		return fmt.Errorf("failed to create canvas folder: %w", err)
+	}
+	return w.writeIfChanged(canvasPath, content)
+}
+
+// generateCanvas lays out notes as file cards in one group per status, connected by edges
+// for related notes and for open tasks that link to another note on the canvas
+func (w *ObsidianWriter) generateCanvas(notes []models.ProcessedNote) (string, error) {
+	canvas := jsonCanvas{Nodes: []canvasNode{}, Edges: []canvasEdge{}}
+
+	byStatus := make(map[string][]models.ProcessedNote)
+	for _, note := range notes {
+		byStatus[noteStatus(&note)] = append(byStatus[noteStatus(&note)], note)
+	}
+
+	column := 0
+	for _, status := range models.ValidStatuses {
+		group := byStatus[status]
+		if len(group) == 0 {
+			continue
+		}
+
+		x := column * (canvasCardWidth + 2*canvasGroupMargin + canvasColumnGap)
+		canvas.Nodes = append(canvas.Nodes, canvasNode{
+			ID:     "status-" + status,
+			Type:   "group",
+			X:      x,
+			Y:      0,
+			Width:  canvasCardWidth + 2*canvasGroupMargin,
+			Height: len(group)*(canvasCardHeight+canvasCardGap) - canvasCardGap + 2*canvasGroupMargin,
+			Color:  canvasStatusColors[status],
+			Label:  fmt.Sprintf("%s (%d)", titleCase(status), len(group)),
+		})
+
+		for i, note := range group {
+			canvas.Nodes = append(canvas.Nodes, canvasNode{
+				ID:     note.ID,
+				Type:   "file",
+				X:      x + canvasGroupMargin,
+				Y:      canvasGroupMargin + i*(canvasCardHeight+canvasCardGap),
+				Width:  canvasCardWidth,
+				Height: canvasCardHeight,
+				File:   w.currentPath(&note),
+			})
+		}
+		column++
+	}
+
+	onCanvas := make(map[string]bool)
+	byFilename := make(map[string]string)
+	for _, note := range notes {
+		onCanvas[note.ID] = true
+		byFilename[strings.ToLower(w.noteFilename(&note))] = note.ID
+		byFilename[strings.ToLower(note.Title)] = note.ID
+	}
+
+	edges := make(map[string]bool)
+	for _, note := range notes {
+		for _, related := range note.RelatedIDs {
+			if !onCanvas[related] || related == note.ID {
+				continue
+			}
+			a, b := min(note.ID, related), max(note.ID, related)
+			id := "related-" + a + "-" + b
+			if edges[id] {
+				continue
+			}
+			edges[id] = true
+			canvas.Edges = append(canvas.Edges, canvasEdge{
+				ID:       id,
+				FromNode: a,
+				ToNode:   b,
+				ToEnd:    "none",
+				Label:    "related",
+			})
+		}
+
+		// A task that links to another note waits on it: the edge points from that note to this one
+		for i, task := range models.ExtractTasks(note.Markdown) {
+			if task.Done {
+				continue
+			}
+			for _, match := range wikilinkPattern.FindAllStringSubmatch(task.Text, -1) {
+				target := strings.ToLower(strings.TrimSuffix(path.Base(strings.TrimSpace(match[1])), ".md"))
+				dependency, ok := byFilename[target]
+				if !ok || dependency == note.ID {
+					continue
+				}
+				id := fmt.Sprintf("task-%s-%d-%s", note.ID, i, dependency)
+				if edges[id] {
+					continue
+				}
+				edges[id] = true
+				canvas.Edges = append(canvas.Edges, canvasEdge{
+					ID:       id,
+					FromNode: dependency,
+					ToNode:   note.ID,
+					Label:    wikilinkPattern.ReplaceAllStringFunc(task.Text, wikilinkText),
+				})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(canvas); err != nil {
+		return "", fmt.Errorf("failed to encode canvas: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// noteHashtags returns the lowercase inline tags of note markdown, outside code blocks
+func noteHashtags(markdown string) []string {
+	var tags []string
+	fence := false
+	for _, line := range strings.Split(markdown, "\n") {
+		if sinkFencePattern.MatchString(line) {
+			fence = !fence
+			continue
+		}
+		if fence {
+			continue
+		}
+		for _, match := range hashtagPattern.FindAllStringSubmatch(line, -1) {
+			tags = append(tags, strings.ToLower(match[1]))
+		}
+	}
+	return tags
+}
+
+// wikilinkText returns the text a wikilink displays: its alias, or else its target
+func wikilinkText(link string) string {
+	inner := strings.TrimSuffix(strings.TrimPrefix(link, "[["), "]]")
+	if i := strings.Index(inner, "|"); i != -1 {
+		return inner[i+1:]
+	}
+	return inner
+}
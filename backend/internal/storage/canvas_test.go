@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+func TestGenerateCanvas(t *testing.T) {
+	w := &ObsidianWriter{folderName: "IdeaForge"}
+	notes := []models.ProcessedNote{
+		{
+			ID:         "note_a",
+			Title:      "Rack",
+			Category:   "homelab",
+			VaultPath:  "IdeaForge/homelab/rack.md",
+			RelatedIDs: []string{"note_b"},
+			Markdown:   "# Rack\n\n- [ ] Mount after [[cabling|the cabling]]\n- [x] Order [[cabling]]\n- [ ] Label [[rack]]",
+		},
+		{
+			ID:         "note_b",
+			Title:      "Cabling",
+			Category:   "homelab",
+			Status:     models.StatusActive,
+			VaultPath:  "IdeaForge/homelab/cabling.md",
+			RelatedIDs: []string{"note_a"},
+			Markdown:   "# Cabling",
+		},
+		{
+			ID:         "note_c",
+			Title:      "Old NAS",
+			Category:   "homelab",
+			Status:     models.StatusArchived,
+			VaultPath:  "IdeaForge/homelab/old-nas.md",
+			RelatedIDs: []string{"note_gone"},
+			Markdown:   "# Old NAS\n\n- [ ] Wipe before [[Missing note]]",
+		},
+	}
+
+	content, err := w.generateCanvas(notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var canvas jsonCanvas
+	if err := json.Unmarshal([]byte(content), &canvas); err != nil {
+		t.Fatalf("canvas is not valid JSON: %v\n%s", err, content)
+	}
+
+	column := canvasCardWidth + 2*canvasGroupMargin + canvasColumnGap
+	wantNodes := []canvasNode{
+		{ID: "status-active", Type: "group", X: 0, Y: 0, Width: canvasCardWidth + 2*canvasGroupMargin, Height: 2*canvasCardHeight + canvasCardGap + 2*canvasGroupMargin, Color: "5", Label: "Active (2)"},
+		{ID: "note_a", Type: "file", X: canvasGroupMargin, Y: canvasGroupMargin, Width: canvasCardWidth, Height: canvasCardHeight, File: "IdeaForge/homelab/rack.md"},
+		{ID: "note_b", Type: "file", X: canvasGroupMargin, Y: canvasGroupMargin + canvasCardHeight + canvasCardGap, Width: canvasCardWidth, Height: canvasCardHeight, File: "IdeaForge/homelab/cabling.md"},
+		{ID: "status-archived", Type: "group", X: column, Y: 0, Width: canvasCardWidth + 2*canvasGroupMargin, Height: canvasCardHeight + 2*canvasGroupMargin, Label: "Archived (1)"},
+		{ID: "note_c", Type: "file", X: column + canvasGroupMargin, Y: canvasGroupMargin, Width: canvasCardWidth, Height: canvasCardHeight, File: "IdeaForge/homelab/old-nas.md"},
+	}
+	if len(canvas.Nodes) != len(wantNodes) {
+		t.Fatalf("nodes = %+v, want %+v", canvas.Nodes, wantNodes)
+	}
+	for i, want := range wantNodes {
+		if canvas.Nodes[i] != want {
+			t.Errorf("node %d = %+v, want %+v", i, canvas.Nodes[i], want)
+		}
+	}
+
+	// Related notes get one undirected edge, open tasks linking to a note on the canvas point
+	// from that note to the task's note; done tasks, self links and missing notes get none
+	wantEdges := []canvasEdge{
+		{ID: "related-note_a-note_b", FromNode: "note_a", ToNode: "note_b", ToEnd: "none", Label: "related"},
+		{ID: "task-note_a-0-note_b", FromNode: "note_b", ToNode: "note_a", Label: "Mount after the cabling"},
+	}
+	if len(canvas.Edges) != len(wantEdges) {
+		t.Fatalf("edges = %+v, want %+v", canvas.Edges, wantEdges)
+	}
+	for i, want := range wantEdges {
+		if canvas.Edges[i] != want {
+			t.Errorf("edge %d = %+v, want %+v", i, canvas.Edges[i], want)
+		}
+	}
+}
+
+func TestGenerateCanvasEmpty(t *testing.T) {
+	w := &ObsidianWriter{folderName: "IdeaForge"}
+
+	content, err := w.generateCanvas(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n\t\"nodes\": [],\n\t\"edges\": []\n}\n"; content != want {
+		t.Errorf("empty canvas = %q, want %q", content, want)
+	}
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// conflictPattern matches Syncthing conflict copies such as
+// "2026-10-17-set-up-k3s.sync-conflict-20261017-153012-ABCDEF1.md"
+var conflictPattern = regexp.MustCompile(`^(.+)\.sync-conflict-(\d{8}-\d{6})-([A-Z0-9]+)\.md$`)
+
+// ConflictFile is a Syncthing conflict copy of an IdeaForge note file
+type ConflictFile struct {
+	Vault        string    `json:"vault"`         // name of the vault folder it was found in
+	Path         string    `json:"path"`          // conflict copy, relative to the vault root
+	OriginalPath string    `json:"original_path"` // file it conflicts with
+	NoteID       string    `json:"note_id"`
+	Device       string    `json:"device"` // short ID of the device whose edit lost
+	DetectedAt   time.Time `json:"detected_at"`
+}
+
+// ScanConflicts finds Syncthing conflict copies in the IdeaForge folder whose original is a
+// note file managed by IdeaForge, identified by the frontmatter id of either copy
+func (w *ObsidianWriter) ScanConflicts() ([]ConflictFile, error) {
+	root := filepath.Join(w.vaultPath, w.folderName)
+
+	conflicts := make([]ConflictFile, 0)
+	err := filepath.WalkDir(root, func(full string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && full == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if entry.IsDir() {
+			if full != root && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == quarantineFolder) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		match := conflictPattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(w.vaultPath, full)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		original := path.Join(path.Dir(rel), match[1]+".md")
+
+		id, _ := w.fileOwner(original)
+		if id == "" {
+			id, _ = w.fileOwner(rel)
+		}
+		if id == "" {
+			return nil
+		}
+
+		detected, err := time.ParseInLocation("20060102-150405", match[2], time.Local)
+		if err != nil {
+			return nil
+		}
+
+		conflicts = append(conflicts, ConflictFile{
+			Vault:        w.name,
+			Path:         rel,
+			OriginalPath: original,
+			NoteID:       id,
+			Device:       match[3],
+			DetectedAt:   detected,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for conflicts: %w", err)
+	}
+
+	return conflicts, nil
+}
+
+// ReadFile returns the content of a vault file, or "" if it does not exist
+func (w *ObsidianWriter) ReadFile(rel string) (string, error) {
+	full, err := w.resolve(rel)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(full)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// RemoveFile deletes a vault file; a file that is already gone is not an error
+func (w *ObsidianWriter) RemoveFile(rel string) error {
+	full, err := w.resolve(rel)
+	if err != nil {
+		return err
+	}
+
+	content, _ := os.ReadFile(full)
+	if err := os.Remove(full); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	w.recordChange(changeDelete, full, "", content)
+
+	return nil
+}
+
+// RenderNote returns the file content WriteNote would produce for a note, without writing it
+func (w *ObsidianWriter) RenderNote(note *models.ProcessedNote) (string, error) {
+	rendered := *note
+	rel, err := w.resolvePath(&rendered)
+	if err != nil {
+		return "", err
+	}
+	rendered.VaultPath = rel
+
+	return w.generateContent(&rendered)
+}
+
+// WriteFile writes raw content to a vault file atomically
+func (w *ObsidianWriter) WriteFile(rel, content string) error {
+	full, err := w.resolve(rel)
+	if err != nil {
+		return err
+	}
+
+	if err := w.mkdirAll(filepath.Dir(full)); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	if err := w.writeFile(full, []byte(content)); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// ComparableContent drops frontmatter that changes on every write, such as the modified
+// timestamp, so versions of a note can be diffed and merged by what was actually edited
+func ComparableContent(content string) string {
+	front, body, ok := splitFrontmatter(content)
+	if !ok {
+		return content
+	}
+
+	var kept []string
+	for _, line := range strings.Split(front, "\n") {
+		if !strings.HasPrefix(line, "modified:") {
+			kept = append(kept, line)
+		}
+	}
+
+	return frontmatterDelimiter + "\n" + strings.Join(kept, "\n") + frontmatterDelimiter + "\n" + body
+}
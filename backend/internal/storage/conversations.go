@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// ListConversation returns the refinement conversation of a note, oldest first
+func (d *Database) ListConversation(noteID string) ([]models.ConversationTurn, error) {
+	rows, err := d.db.Query(`
+		SELECT role, content, created_at
+		FROM note_conversations WHERE note_id = ?
+		ORDER BY id ASC
+	`, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conversation: %w", err)
+	}
+	defer rows.Close()
+
+	turns := make([]models.ConversationTurn, 0)
+	for rows.Next() {
+		var turn models.ConversationTurn
+		if err := rows.Scan(&turn.Role, &turn.Content, &turn.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation turn: %w", err)
+		}
+		turns = append(turns, turn)
+	}
+
+	return turns, rows.Err()
+}
+
+// AppendConversation stores new turns of a note's refinement conversation
+func (d *Database) AppendConversation(noteID string, turns ...models.ConversationTurn) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, turn := range turns {
+		_, err := tx.Exec(`
+			INSERT INTO note_conversations (note_id, role, content, created_at)
+			VALUES (?, ?, ?, ?)
+		`, noteID, turn.Role, turn.Content, turn.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert conversation turn: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+const (
+	defaultDailyNoteFormat  = "YYYY-MM-DD"
+	defaultDailyNoteHeading = "## IdeaForge"
+	// dailyNoteAttempts bounds retries when the daily note changes while it is being edited
+	dailyNoteAttempts = 3
+)
+
+// dailyNotesConfig mirrors Obsidian's .obsidian/daily-notes.json
+type dailyNotesConfig struct {
+	Folder   string `json:"folder"`
+	Format   string `json:"format"`
+	Template string `json:"template"`
+	heading  string
+}
+
+// loadDailyNotesConfig reads the vault's Daily Notes settings; DAILY_NOTES_FOLDER and DAILY_NOTES_FORMAT override them
+func loadDailyNotesConfig(vaultPath string) (*dailyNotesConfig, error) {
+	cfg := &dailyNotesConfig{}
+
+	data, err := os.ReadFile(filepath.Join(vaultPath, ".obsidian", "daily-notes.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read daily notes config: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse daily notes config: %w", err)
+		}
+	}
+
+	if folder := os.Getenv("DAILY_NOTES_FOLDER"); folder != "" {
+		cfg.Folder = folder
+	}
+	if format := os.Getenv("DAILY_NOTES_FORMAT"); format != "" {
+		cfg.Format = format
+	}
+	if cfg.Format == "" {
+		cfg.Format = defaultDailyNoteFormat
+	}
+
+	cfg.heading = os.Getenv("DAILY_NOTE_HEADING")
+	if cfg.heading == "" {
+		cfg.heading = defaultDailyNoteHeading
+	}
+
+	return cfg, nil
+}
+
+// AppendToDailyNote adds a link to the note under the configured heading of that day's daily note.
+// The daily note is created if it is missing. Does nothing when daily notes are disabled.
+func (w *ObsidianWriter) AppendToDailyNote(note *models.ProcessedNote) error {
+	if w.daily == nil {
+		return nil
+	}
+
+	w.dailyMu.Lock()
+	defer w.dailyMu.Unlock()
+
+	filePath := filepath.Join(w.vaultPath, filepath.FromSlash(w.daily.Folder),
+		filepath.FromSlash(formatMoment(note.CreatedAt, w.daily.Format))+".md")
+
+	// Prevent path traversal
+	if !strings.HasPrefix(filepath.Clean(filePath), filepath.Clean(w.vaultPath)) {
+		return fmt.Errorf("invalid file path: attempted path traversal")
+	}
+
+	if err := w.mkdirAll(filepath.Dir(filePath)); err != nil {
+		return fmt.Errorf("failed to create daily notes folder: %w", err)
+	}
+
+	target := w.noteFilename(note)
+	line := fmt.Sprintf("- [[%s|%s]] (%s)", target, wikilinkAlias(note.Title), note.Category)
+
+	for attempt := 0; attempt < dailyNoteAttempts; attempt++ {
+		before, statErr := os.Stat(filePath)
+
+		var content string
+		if statErr == nil {
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				return fmt.Errorf("failed to read daily note: %w", err)
+			}
+			content = string(data)
+		} else if os.IsNotExist(statErr) {
+			content = w.dailyTemplate(note.CreatedAt)
+		} else {
+			return fmt.Errorf("failed to stat daily note: %w", statErr)
+		}
+
+		// Already linked, e.g. when a write is retried
+		if strings.Contains(content, "[["+target+"|") {
+			return nil
+		}
+
+		updated := insertUnderHeading(content, w.daily.heading, line)
+
+		// Another writer (Syncthing, Obsidian) changed the file while we edited it: start over
+		if fileChanged(filePath, before) {
+			continue
+		}
+
+		return w.writeFile(filePath, []byte(updated))
+	}
+
+	return fmt.Errorf("daily note %s kept changing, giving up", filePath)
+}
+
+// fileChanged reports whether a file differs from an earlier stat (nil meaning it did not exist)
+func fileChanged(path string, before os.FileInfo) bool {
+	after, err := os.Stat(path)
+	if before == nil {
+		return err == nil
+	}
+	if err != nil {
+		return true
+	}
+	return !after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size()
+}
+
+// templateDatePattern matches {{date}} and {{date:FORMAT}} in daily note templates
+var templateDatePattern = regexp.MustCompile(`\{\{date(?::([^}]*))?\}\}`)
+
+// dailyTemplate returns the initial content of a new daily note from the configured template, if any
+func (w *ObsidianWriter) dailyTemplate(date time.Time) string {
+	if w.daily.Template == "" {
+		return ""
+	}
+
+	templatePath := filepath.Join(w.vaultPath, filepath.FromSlash(w.daily.Template))
+	if !strings.HasSuffix(templatePath, ".md") {
+		templatePath += ".md"
+	}
+
+	data, err := os.ReadFile(templatePath)
+	if err != nil {
+		return ""
+	}
+
+	content := templateDatePattern.ReplaceAllStringFunc(string(data), func(match string) string {
+		format := templateDatePattern.FindStringSubmatch(match)[1]
+		if format == "" {
+			format = w.daily.Format
+		}
+		return formatMoment(date, format)
+	})
+	return strings.ReplaceAll(content, "{{title}}", formatMoment(date, w.daily.Format))
+}
+
+// insertUnderHeading adds a line at the end of the section started by heading, creating the section if needed
+func insertUnderHeading(content, heading, line string) string {
+	if strings.TrimSpace(content) == "" {
+		return heading + "\n" + line + "\n"
+	}
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	start := -1
+	for i, l := range lines {
+		if strings.TrimSpace(l) == heading {
+			start = i
+			break
+		}
+	}
+
+	if start < 0 {
+		return strings.TrimRight(content, "\n") + "\n\n" + heading + "\n" + line + "\n"
+	}
+
+	// The section ends at the next heading of the same or a higher level
+	level := headingLevel(heading)
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if l := headingLevel(lines[i]); l > 0 && l <= level {
+			end = i
+			break
+		}
+	}
+
+	// Insert after the last non-blank line of the section
+	pos := end
+	for pos-1 > start && strings.TrimSpace(lines[pos-1]) == "" {
+		pos--
+	}
+
+	result := make([]string, 0, len(lines)+1)
+	result = append(result, lines[:pos]...)
+	result = append(result, line)
+	result = append(result, lines[pos:]...)
+
+	return strings.Join(result, "\n") + "\n"
+}
+
+// headingLevel returns the markdown heading level of a line, or 0 if it is not a heading
+func headingLevel(line string) int {
+	trimmed := strings.TrimSpace(line)
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(trimmed) && trimmed[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+// wikilinkAlias strips characters that would break a [[target|alias]] link
+func wikilinkAlias(title string) string {
+	return strings.NewReplacer("|", "-", "[", "(", "]", ")", "\n", " ").Replace(title)
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// SaveEmbedding stores the embedding vector of a note, replacing any previous one. Nothing is
+// stored for a note that was deleted while its vector was computed.
+func (d *Database) SaveEmbedding(noteID, provider string, vector []float32) error {
+	_, err := d.db.Exec(`
+		INSERT INTO note_embeddings (note_id, provider, dims, vector, updated_at)
+		SELECT ?, ?, ?, ?, ?
+		WHERE EXISTS (SELECT 1 FROM notes WHERE id = ?)
+		ON CONFLICT(note_id) DO UPDATE SET
+			provider = excluded.provider,
+			dims = excluded.dims,
+			vector = excluded.vector,
+			updated_at = excluded.updated_at
+	`, noteID, provider, len(vector), encodeVector(vector), time.Now(), noteID)
+	if err != nil {
+		return fmt.Errorf("failed to save embedding: %w", err)
+	}
+	return nil
+}
+
+// AllEmbeddings returns every stored vector produced by the given provider, keyed by note ID
+func (d *Database) AllEmbeddings(provider string) (map[string][]float32, error) {
+	rows, err := d.db.Query("SELECT note_id, vector FROM note_embeddings WHERE provider = ?", provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	vectors := make(map[string][]float32)
+	for rows.Next() {
+		var noteID string
+		var blob []byte
+		if err := rows.Scan(&noteID, &blob); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding: %w", err)
+		}
+		vectors[noteID] = decodeVector(blob)
+	}
+
+	return vectors, rows.Err()
+}
+
+// NotesWithoutEmbedding returns the notes that have no vector from the given provider
+func (d *Database) NotesWithoutEmbedding(provider string) ([]models.ProcessedNote, error) {
+	rows, err := d.db.Query(`
+		SELECT `+noteColumns+` FROM notes
+		WHERE id NOT IN (SELECT note_id FROM note_embeddings WHERE provider = ?)
+		ORDER BY created_at DESC
+	`, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes without embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	notes := make([]models.ProcessedNote, 0)
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes = append(notes, *note)
+	}
+
+	return notes, rows.Err()
+}
+
+// encodeVector packs a vector as little-endian float32 values
+func encodeVector(vector []float32) []byte {
+	blob := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(v))
+	}
+	return blob
+}
+
+// decodeVector unpacks a vector stored by encodeVector
+func decodeVector(blob []byte) []float32 {
+	vector := make([]float32, len(blob)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:]))
+	}
+	return vector
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+func TestSaveEmbeddingSkipsDeletedNotes(t *testing.T) {
+	t.Setenv("DATABASE_PATH", filepath.Join(t.TempDir(), "ideaforge.db"))
+	db, err := NewDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	note := &models.ProcessedNote{Title: "Rack", Category: "homelab", Markdown: "# Rack", Original: "rack", CreatedAt: time.Now()}
+	if err := db.CreateNote(note, models.RevisionSourceAPI); err != nil {
+		t.Fatal(err)
+	}
+
+	vector := []float32{0.6, 0.8}
+	for _, id := range []string{note.ID, "note_deleted"} {
+		if err := db.SaveEmbedding(id, "hash:2", vector); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stored, err := db.AllEmbeddings("hash:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]float32{note.ID: vector}; !reflect.DeepEqual(stored, want) {
+		t.Errorf("embeddings = %v, want %v", stored, want)
+	}
+}
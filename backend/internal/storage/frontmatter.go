@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-yaml"
+)
+
+// frontmatterDelimiter opens and closes a YAML frontmatter block
+const frontmatterDelimiter = "---"
+
+// splitFrontmatter separates a markdown document into its YAML frontmatter and body.
+// ok is false when the document does not start with a frontmatter block.
+func splitFrontmatter(content string) (front, body string, ok bool) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, frontmatterDelimiter+"\n") {
+		return "", content, false
+	}
+
+	rest := content[len(frontmatterDelimiter)+1:]
+	if strings.HasPrefix(rest, frontmatterDelimiter+"\n") || rest == frontmatterDelimiter {
+		return "", strings.TrimPrefix(rest[len(frontmatterDelimiter):], "\n"), true
+	}
+
+	end := strings.Index(rest, "\n"+frontmatterDelimiter+"\n")
+	if end == -1 {
+		if !strings.HasSuffix(rest, "\n"+frontmatterDelimiter) {
+			return "", content, false
+		}
+		end = len(rest) - len(frontmatterDelimiter) - 1
+	}
+
+	front = rest[:end+1]
+	body = strings.TrimPrefix(rest[end+1+len(frontmatterDelimiter):], "\n")
+	return front, body, true
+}
+
+// parseFrontmatter decodes a frontmatter block, keeping the order of its keys
+func parseFrontmatter(front string) (yaml.MapSlice, error) {
+	var fields yaml.MapSlice
+	if strings.TrimSpace(front) == "" {
+		return fields, nil
+	}
+
+	if err := yaml.UnmarshalWithOptions([]byte(front), &fields, yaml.UseOrderedMap()); err != nil {
+		return nil, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+
+	return fields, nil
+}
+
+// encodeFrontmatter encodes frontmatter fields in the block style Obsidian writes
+func encodeFrontmatter(fields yaml.MapSlice) (string, error) {
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	data, err := yaml.MarshalWithOptions(fields, yaml.IndentSequence(true))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode frontmatter: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// yaml12NonStringPattern matches plain scalars a YAML 1.2 reader such as Obsidian's
+// resolves to null, a bool or a number (the core schema)
+var yaml12NonStringPattern = regexp.MustCompile(`^(?:~|null|Null|NULL|true|True|TRUE|false|False|FALSE|` +
+	`[-+]?[0-9]+|0o[0-7]+|0x[0-9a-fA-F]+|` +
+	`[-+]?(?:\.[0-9]+|[0-9]+(?:\.[0-9]*)?)(?:[eE][-+]?[0-9]+)?|[-+]?\.(?:inf|Inf|INF)|\.(?:nan|NaN|NAN))$`)
+
+// yamlScalar encodes a value for use after a key or list marker in a frontmatter template.
+// Strings with line breaks, tabs or other control characters, and strings a YAML 1.2 reader
+// would not read back as strings, are double-quoted so they stay on one line and round-trip.
+func yamlScalar(value any) (string, error) {
+	if s, ok := value.(string); ok && needsYAMLQuotes(s) {
+		// Go's escapes are a subset of YAML's double-quoted escapes
+		return strconv.Quote(strings.ToValidUTF8(s, "\uFFFD")), nil
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// needsYAMLQuotes reports whether a string must be double-quoted rather than left to the encoder
+func needsYAMLQuotes(s string) bool {
+	if yaml12NonStringPattern.MatchString(s) {
+		return true
+	}
+	for _, r := range s {
+		if r != ' ' && !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// preserveFrontmatter carries frontmatter keys from the existing version of a file into
+// freshly rendered content. Keys the template sets, or could set under another condition
+// (owned), belong to IdeaForge and are overwritten or dropped; any other key was added by
+// hand and is appended unchanged.
+func preserveFrontmatter(rendered, existing string, owned []string) (string, error) {
+	front, body, ok := splitFrontmatter(rendered)
+	if !ok {
+		return rendered, nil
+	}
+
+	existingFront, _, ok := splitFrontmatter(existing)
+	if !ok {
+		return rendered, nil
+	}
+
+	generated, err := parseFrontmatter(front)
+	if err != nil {
+		return "", fmt.Errorf("rendered note has %w", err)
+	}
+
+	previous, err := parseFrontmatter(existingFront)
+	if err != nil {
+		return "", fmt.Errorf("existing note has %w", err)
+	}
+
+	keys := make(map[string]bool, len(generated)+len(owned))
+	for _, key := range owned {
+		keys[key] = true
+	}
+	for _, item := range generated {
+		keys[fmt.Sprint(item.Key)] = true
+	}
+
+	var extra yaml.MapSlice
+	for _, item := range previous {
+		if !keys[fmt.Sprint(item.Key)] {
+			extra = append(extra, item)
+		}
+	}
+
+	if len(extra) == 0 {
+		return rendered, nil
+	}
+
+	encoded, err := encodeFrontmatter(extra)
+	if err != nil {
+		return "", err
+	}
+
+	return frontmatterDelimiter + "\n" + front + encoded + frontmatterDelimiter + "\n" + body, nil
+}
+
+// markdownInline escapes text for use inside inline markdown such as an emphasised
+// footer line, flattening line breaks so the text cannot start new blocks
+func markdownInline(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', '*', '_', '`', '[', ']', '<', '>', '#', '|':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// frontmatterValue returns the value of a top-level frontmatter key, or nil
+func frontmatterValue(fields yaml.MapSlice, key string) any {
+	for _, item := range fields {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+// frontmatterString returns a top-level frontmatter value as a string, or "" if it is missing
+func frontmatterString(fields yaml.MapSlice, key string) string {
+	value := frontmatterValue(fields, key)
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestYAMLScalarRoundTrip(t *testing.T) {
+	values := []string{
+		`Say "hello"`,
+		`It's done`,
+		"key: value",
+		"ratio 3:2",
+		"#homelab",
+		"C# and F#",
+		"first line\nsecond line",
+		"windows\r\nline",
+		"tab\there",
+		"nel\u0085line",
+		"1e3",
+		"42",
+		"-7",
+		"3.14",
+		".5",
+		"0x1F",
+		"0o17",
+		".inf",
+		".NaN",
+		"true",
+		"False",
+		"yes",
+		"on",
+		"null",
+		"~",
+		"",
+		"- list marker",
+		"[brackets]",
+		"{braces}",
+		"& anchor",
+		"* alias",
+		"! tag",
+		"| block",
+		"> folded",
+		"% directive",
+		"@ at",
+		" leading space",
+		"trailing space ",
+		"Ünïcödé ünd 日本語",
+	}
+
+	for _, value := range values {
+		scalar, err := yamlScalar(value)
+		if err != nil {
+			t.Errorf("yamlScalar(%q): %v", value, err)
+			continue
+		}
+		if strings.ContainsAny(scalar, "\r\n\t") {
+			t.Errorf("yamlScalar(%q) = %q spans lines or holds a raw tab", value, scalar)
+		}
+
+		fields, err := parseFrontmatter("title: " + scalar + "\n")
+		if err != nil {
+			t.Errorf("yamlScalar(%q) = %q does not parse: %v", value, scalar, err)
+			continue
+		}
+		got, ok := frontmatterValue(fields, "title").(string)
+		if !ok || got != value {
+			t.Errorf("yamlScalar(%q) = %q reads back as %#v", value, scalar, frontmatterValue(fields, "title"))
+		}
+	}
+}
+
+// YAML 1.2 readers resolve these plain scalars to numbers, bools or null, so they must be quoted
+func TestYAMLScalarQuotesNonStrings(t *testing.T) {
+	for _, value := range []string{"1e3", "1E-2", "42", "+1", ".5", "0x1F", "0o17", ".inf", "-.Inf", ".nan", "true", "FALSE", "null", "~", ""} {
+		scalar, err := yamlScalar(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(scalar, `"`) && !strings.HasPrefix(scalar, `'`) {
+			t.Errorf("yamlScalar(%q) = %s is not quoted", value, scalar)
+		}
+	}
+}
+
+func TestYAMLScalarNonStrings(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{42, "42"},
+		{true, "true"},
+		{2.5, "2.5"},
+	}
+	for _, tt := range tests {
+		got, err := yamlScalar(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("yamlScalar(%v) = %q, %v; want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestPreserveFrontmatterKeepsHandAddedKeys(t *testing.T) {
+	existing := "---\n" +
+		"id: note_1\n" +
+		"status: active\n" +
+		"cssclasses:\n  - wide\n" +
+		"rating: 4\n" +
+		"---\n\n# Old body\n"
+	rendered := "---\nid: note_1\nstatus: completed\n---\n\n# New body\n"
+
+	merged, err := preserveFrontmatter(rendered, existing, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	front, body, ok := splitFrontmatter(merged)
+	if !ok {
+		t.Fatalf("merged note has no frontmatter:\n%s", merged)
+	}
+	fields, err := parseFrontmatter(front)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := frontmatterString(fields, "status"); got != "completed" {
+		t.Errorf("status = %q, want the rendered value", got)
+	}
+	if got := frontmatterString(fields, "rating"); got != "4" {
+		t.Errorf("rating = %q, want the hand-added value", got)
+	}
+	if classes, ok := frontmatterValue(fields, "cssclasses").([]any); !ok || len(classes) != 1 || classes[0] != "wide" {
+		t.Errorf("cssclasses = %#v", frontmatterValue(fields, "cssclasses"))
+	}
+	if strings.Count(front, "status:") != 1 {
+		t.Errorf("status written twice:\n%s", front)
+	}
+	if body != "\n# New body\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestPreserveFrontmatterWithoutExistingFrontmatter(t *testing.T) {
+	rendered := "---\nid: note_1\n---\n\nbody\n"
+	merged, err := preserveFrontmatter(rendered, "just text\n", nil)
+	if err != nil || merged != rendered {
+		t.Errorf("merged = %q, %v; want the rendered note unchanged", merged, err)
+	}
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+const (
+	// mocName is the Map of Content note; the [[IdeaForge]] footer link in every note resolves to it
+	mocName = "IdeaForge"
+	// categoryIndexName is the per-category index note
+	categoryIndexName = "_index"
+	// mocRecentNotes is the number of recent notes listed in the Map of Content
+	mocRecentNotes = 10
+)
+
+// WriteIndexes regenerates the per-category index notes and the top-level Map of Content
+func (w *ObsidianWriter) WriteIndexes(notes []models.ProcessedNote) error {
+	byCategory := make(map[string][]models.ProcessedNote)
+	for _, note := range notes {
+		byCategory[note.Category] = append(byCategory[note.Category], note)
+	}
+
+	for _, category := range indexCategories(byCategory) {
+		categoryPath := filepath.Join(w.vaultPath, w.folderName, category)
+		indexPath := filepath.Join(categoryPath, categoryIndexName+".md")
+
+		// Don't create folders for empty categories, but keep an existing index up to date
+		if len(byCategory[category]) == 0 {
+			if _, err := os.Stat(indexPath); err != nil {
+				continue
+			}
+		}
+
+		if err := w.mkdirAll(categoryPath); err != nil {
+			return fmt.Errorf("failed to create category folder: %w", err)
+		}
+
+		content := w.generateCategoryIndex(category, byCategory[category])
+		if err := w.writeIfChanged(indexPath, content); err != nil {
+			return err
+		}
+	}
+
+	mocPath := filepath.Join(w.vaultPath, w.folderName, mocName+".md")
+	if err := w.mkdirAll(filepath.Dir(mocPath)); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+	return w.writeIfChanged(mocPath, w.generateMOC(byCategory, notes))
+}
+
+// indexCategories returns the known categories followed by any other category that has notes
+func indexCategories(byCategory map[string][]models.ProcessedNote) []string {
+	categories := append([]string{}, models.ValidCategories...)
+
+	var extra []string
+	for category := range byCategory {
+		if !models.IsValidCategory(category) {
+			extra = append(extra, category)
+		}
+	}
+	sort.Strings(extra)
+
+	return append(categories, extra...)
+}
+
+// generateCategoryIndex lists a category's notes grouped by status
+func (w *ObsidianWriter) generateCategoryIndex(category string, notes []models.ProcessedNote) string {
+	var sb strings.Builder
+
+	sb.WriteString("---\n")
+	sb.WriteString(fmt.Sprintf("category: %s\n", category))
+	sb.WriteString("source: idea-forge\n")
+	sb.WriteString("type: index\n")
+	sb.WriteString("tags:\n")
+	sb.WriteString("  - idea-forge\n")
+	sb.WriteString(fmt.Sprintf("  - %s\n", category))
+	sb.WriteString("---\n\n")
+
+	sb.WriteString(fmt.Sprintf("# %s\n\n", titleCase(category)))
+	sb.WriteString(fmt.Sprintf("Back to [[%s]] · %d notes", mocName, len(notes)))
+	if w.canvas != nil {
+		sb.WriteString(fmt.Sprintf(" · [[%s/%s/%s.canvas|Board]]", w.folderName, category, categoryCanvasName))
+	}
+	sb.WriteString("\n")
+
+	byStatus := make(map[string][]models.ProcessedNote)
+	for _, note := range notes {
+		byStatus[noteStatus(&note)] = append(byStatus[noteStatus(&note)], note)
+	}
+
+	for _, status := range models.ValidStatuses {
+		group := byStatus[status]
+		if len(group) == 0 {
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("\n## %s (%d)\n\n", titleCase(status), len(group)))
+		sb.WriteString("| Note | Progress | Created |\n")
+		sb.WriteString("| --- | --- | --- |\n")
+		for _, note := range group {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s |\n",
+				w.noteLink(&note), progressText(&note), note.CreatedAt.Format("2006-01-02")))
+		}
+	}
+
+	return sb.String()
+}
+
+// generateMOC builds the top-level Map of Content linking every category index
+func (w *ObsidianWriter) generateMOC(byCategory map[string][]models.ProcessedNote, notes []models.ProcessedNote) string {
+	var sb strings.Builder
+
+	sb.WriteString("---\n")
+	sb.WriteString("source: idea-forge\n")
+	sb.WriteString("type: index\n")
+	sb.WriteString("tags:\n")
+	sb.WriteString("  - idea-forge\n")
+	sb.WriteString("---\n\n")
+
+	sb.WriteString(fmt.Sprintf("# %s\n\n", mocName))
+	sb.WriteString("## Categories\n\n")
+	sb.WriteString("| Category | Active | Completed | Archived |\n")
+	sb.WriteString("| --- | --- | --- | --- |\n")
+	for _, category := range indexCategories(byCategory) {
+		if len(byCategory[category]) == 0 {
+			continue
+		}
+
+		counts := make(map[string]int)
+		for _, note := range byCategory[category] {
+			counts[noteStatus(&note)]++
+		}
+		sb.WriteString(fmt.Sprintf("| [[%s/%s/%s\\|%s]] | %d | %d | %d |\n",
+			w.folderName, category, categoryIndexName, titleCase(category),
+			counts[models.StatusActive], counts[models.StatusCompleted], counts[models.StatusArchived]))
+	}
+
+	recent := append([]models.ProcessedNote{}, notes...)
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].CreatedAt.After(recent[j].CreatedAt)
+	})
+	if len(recent) > mocRecentNotes {
+		recent = recent[:mocRecentNotes]
+	}
+
+	if len(recent) > 0 {
+		sb.WriteString("\n## Recent Notes\n\n")
+		sb.WriteString("| Note | Category | Status | Progress | Created |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, note := range recent {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+				w.noteLink(&note), note.Category, noteStatus(&note), progressText(&note), note.CreatedAt.Format("2006-01-02")))
+		}
+	}
+
+	return sb.String()
+}
+
+// noteLink returns a wikilink to a note, with the alias pipe escaped for use inside tables
+func (w *ObsidianWriter) noteLink(note *models.ProcessedNote) string {
+	target := w.noteFilename(note)
+	return fmt.Sprintf("[[%s\\|%s]]", target, wikilinkAlias(note.Title))
+}
+
+// noteStatus returns the status of a note, treating an empty status as active
+func noteStatus(note *models.ProcessedNote) string {
+	if note.Status == "" {
+		return models.StatusActive
+	}
+	return note.Status
+}
+
+// progressText formats task completion such as "3/5 (60%)"
+func progressText(note *models.ProcessedNote) string {
+	done, total := models.TaskProgress(note.Markdown)
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d (%d%%)", done, total, done*100/total)
+}
+
+// titleCase capitalizes the first letter of a word
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// writeIfChanged writes a file atomically unless it already has the given content,
+// which avoids needless Syncthing churn for regenerated files
+func (w *ObsidianWriter) writeIfChanged(path, content string) error {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, []byte(content)) {
+		return nil
+	}
+	return w.writeFile(path, []byte(content))
+}
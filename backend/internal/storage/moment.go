@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// momentTokens are the moment.js format tokens supported for daily note paths, longest first
+var momentTokens = []string{
+	"YYYY", "GGGG", "gggg", "MMMM", "dddd",
+	"MMM", "ddd",
+	"YY", "MM", "DD", "Do", "WW", "ww", "HH", "mm", "ss",
+	"M", "D", "W", "w", "d", "E",
+}
+
+// formatMoment renders t using a moment.js format string, as used by Obsidian's Daily Notes plugin.
+// Text inside square brackets is copied literally.
+func formatMoment(t time.Time, format string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(format); {
+		if format[i] == '[' {
+			end := strings.IndexByte(format[i:], ']')
+			if end > 0 {
+				sb.WriteString(format[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+
+		matched := false
+		for _, token := range momentTokens {
+			if strings.HasPrefix(format[i:], token) {
+				sb.WriteString(momentValue(t, token))
+				i += len(token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			sb.WriteByte(format[i])
+			i++
+		}
+	}
+
+	return sb.String()
+}
+
+// momentValue renders a single moment.js token
+func momentValue(t time.Time, token string) string {
+	isoYear, isoWeek := t.ISOWeek()
+
+	switch token {
+	case "YYYY":
+		return fmt.Sprintf("%04d", t.Year())
+	case "YY":
+		return fmt.Sprintf("%02d", t.Year()%100)
+	case "GGGG", "gggg":
+		return fmt.Sprintf("%04d", isoYear)
+	case "MMMM":
+		return t.Month().String()
+	case "MMM":
+		return t.Month().String()[:3]
+	case "MM":
+		return fmt.Sprintf("%02d", int(t.Month()))
+	case "M":
+		return fmt.Sprintf("%d", int(t.Month()))
+	case "DD":
+		return fmt.Sprintf("%02d", t.Day())
+	case "D":
+		return fmt.Sprintf("%d", t.Day())
+	case "Do":
+		return ordinal(t.Day())
+	case "dddd":
+		return t.Weekday().String()
+	case "ddd":
+		return t.Weekday().String()[:3]
+	case "d":
+		return fmt.Sprintf("%d", int(t.Weekday()))
+	case "E":
+		return fmt.Sprintf("%d", (int(t.Weekday())+6)%7+1)
+	case "WW", "ww":
+		return fmt.Sprintf("%02d", isoWeek)
+	case "W", "w":
+		return fmt.Sprintf("%d", isoWeek)
+	case "HH":
+		return fmt.Sprintf("%02d", t.Hour())
+	case "mm":
+		return fmt.Sprintf("%02d", t.Minute())
+	case "ss":
+		return fmt.Sprintf("%02d", t.Second())
+	}
+	return token
+}
+
+// ordinal formats a day of the month as 1st, 2nd, 3rd, 4th, ...
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
@@ -1,158 +1,316 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/kilo40/idea-forge/internal/models"
 )
 
-// ObsidianWriter handles writing notes to an Obsidian vault
+// ObsidianWriter handles writing notes to the IdeaForge folder of an Obsidian vault
 type ObsidianWriter struct {
+	name       string // vault name, with the folder if a route gave it one
 	vaultPath  string
 	folderName string
+	templates  *noteTemplates
+	tasks      taskOutput
+	daily      *dailyNotesConfig // nil when daily note integration is disabled
+	dailyMu    sync.Mutex
+	perms      *vaultPermissions
+	probe      vaultProbe
+	git        *vaultGit     // nil unless vault changes are committed to git
+	canvas     *canvasConfig // nil unless canvases are generated
+	related    RelatedFunc   // nil until set; notes then have no Related section
+
+	attachmentStore *AttachmentStore
+	attachments     AttachmentsFunc // nil until set; notes then have no Attachments section
 }
 
-// NewObsidianWriter creates a new Obsidian writer
+// RelatedFunc returns the notes listed in a note's Related section: the notes it relates to
+// and the notes that relate to it
+type RelatedFunc func(note *models.ProcessedNote) []models.ProcessedNote
+
+// SetRelated sets how the related notes of a note are looked up when its file is rendered
+func (w *ObsidianWriter) SetRelated(fn RelatedFunc) {
+	w.related = fn
+}
+
+// NewObsidianWriter creates the writer of the default vault, OBSIDIAN_VAULT_PATH
 func NewObsidianWriter() (*ObsidianWriter, error) {
 	vaultPath := os.Getenv("OBSIDIAN_VAULT_PATH")
 	if vaultPath == "" {
 		return nil, fmt.Errorf("OBSIDIAN_VAULT_PATH environment variable is required")
 	}
 
-	folderName := os.Getenv("OBSIDIAN_FOLDER")
-	if folderName == "" {
-		folderName = "IdeaForge"
+	return openObsidianWriter(defaultVaultName, vaultPath, defaultFolderName(), nil)
+}
+
+// defaultFolderName is the IdeaForge folder, OBSIDIAN_FOLDER, used unless a route names another
+func defaultFolderName() string {
+	if folderName := os.Getenv("OBSIDIAN_FOLDER"); folderName != "" {
+		return folderName
 	}
+	return "IdeaForge"
+}
 
+// openObsidianWriter creates a writer for a folder of a vault. A writer of another folder
+// of the same vault is passed as shared, so both commit through one git batcher.
+func openObsidianWriter(name, vaultPath, folderName string, shared *ObsidianWriter) (*ObsidianWriter, error) {
 	// Verify vault path exists
 	if _, err := os.Stat(vaultPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("vault path does not exist: %s", vaultPath)
 	}
 
-	return &ObsidianWriter{
+	templates, err := loadNoteTemplates(os.Getenv("OBSIDIAN_TEMPLATES_DIR"))
+	if err != nil {
+		return nil, err
+	}
+
+	perms, err := loadVaultPermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &ObsidianWriter{
+		name:       name,
 		vaultPath:  vaultPath,
 		folderName: folderName,
-	}, nil
+		templates:  templates,
+		tasks:      parseTaskOutput(os.Getenv("OBSIDIAN_TASK_FORMAT")),
+		perms:      perms,
+	}
+
+	writer.probe = writer.selfCheck()
+	if writer.probe.err != nil {
+		log.Printf("Warning: Vault self-check of %s failed: %v", name, writer.probe.err)
+	}
+
+	if shared != nil {
+		// One batch of commits per repository, so the folders don't race for the git index
+		writer.git = shared.git
+	} else if git, err := loadVaultGit(vaultPath); err != nil {
+		log.Printf("Warning: Vault git commits disabled for %s: %v", name, err)
+	} else {
+		writer.git = git
+	}
+
+	if os.Getenv("OBSIDIAN_CANVAS") == "true" {
+		writer.canvas = loadCanvasConfig()
+	}
+
+	if os.Getenv("DAILY_NOTES_ENABLED") == "true" {
+		daily, err := loadDailyNotesConfig(vaultPath)
+		if err != nil {
+			log.Printf("Warning: Daily note integration disabled for %s: %v", name, err)
+		} else {
+			writer.daily = daily
+		}
+	}
+
+	return writer, nil
 }
 
-// WriteNote writes a processed note to the Obsidian vault
+// WriteNote writes a processed note to the Obsidian vault.
+// The note's VaultPath is set to the file it was written to.
 func (w *ObsidianWriter) WriteNote(note *models.ProcessedNote) error {
+	return w.writeNote(note, "")
+}
+
+// WriteMovedNote writes a note whose file is moving here from another vault. The frontmatter
+// keys added by hand to moved, the content of the old file, are kept as WriteNote keeps them.
+func (w *ObsidianWriter) WriteMovedNote(note *models.ProcessedNote, moved string) error {
+	return w.writeNote(note, moved)
+}
+
+// ReadNoteFile returns the content of the file a note was last written to, or "" if there is none
+func (w *ObsidianWriter) ReadNoteFile(note *models.ProcessedNote) (string, error) {
+	return w.ReadFile(w.currentPath(note))
+}
+
+// writeNote writes a note, keeping the hand-added frontmatter of the existing file, or of
+// previous when the note has no file here yet
+func (w *ObsidianWriter) writeNote(note *models.ProcessedNote, previous string) error {
+	rel, err := w.resolvePath(note)
+	if err != nil {
+		return err
+	}
+
+	filePath, err := w.resolve(rel)
+	if err != nil {
+		return err
+	}
+
 	// Create category folder if it doesn't exist
-	categoryPath := filepath.Join(w.vaultPath, w.folderName, note.Category)
-	if err := os.MkdirAll(categoryPath, 0755); err != nil {
+	if err := w.mkdirAll(filepath.Dir(filePath)); err != nil {
 		return fmt.Errorf("failed to create category folder: %w", err)
 	}
 
-	// Generate filename
-	filename := w.generateFilename(note)
-	filePath := filepath.Join(categoryPath, filename)
+	note.VaultPath = rel
 
-	// Prevent path traversal
-	if !strings.HasPrefix(filepath.Clean(filePath), filepath.Clean(w.vaultPath)) {
-		return fmt.Errorf("invalid file path: attempted path traversal")
+	// Copy attachments first, so the embeds never point at a missing file
+	if w.attachments != nil {
+		if err := w.writeAttachments(w.attachments(note)); err != nil {
+			return err
+		}
 	}
 
 	// Generate file content
-	content := w.generateContent(note)
+	content, err := w.generateContent(note)
+	if err != nil {
+		return err
+	}
 
-	// Write file
-	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+	// Keep frontmatter keys added by hand to an earlier version of the file
+	existing, err := os.ReadFile(filePath)
+	if err != nil && previous != "" {
+		existing, err = []byte(previous), nil
+	}
+	if err == nil {
+		merged, err := preserveFrontmatter(content, string(existing), w.templates.forCategory(note.Category).keys)
+		if err != nil {
+			log.Printf("Warning: Not preserving frontmatter of %s: %v", filePath, err)
+		} else {
+			content = merged
+		}
+	}
+
+	// Write file atomically so Obsidian and Syncthing never pick up a partial note
+	if err := w.writeFile(filePath, []byte(content)); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
 	return nil
 }
 
+// MoveNote renames the file of a note whose title or category changed, so the rewrite
+// that follows keeps any frontmatter added by hand. The note's VaultPath is set to the new file.
+func (w *ObsidianWriter) MoveNote(previous, note *models.ProcessedNote) error {
+	oldRel := w.currentPath(previous)
+	newRel, err := w.resolvePath(note)
+	if err != nil {
+		return err
+	}
+
+	if oldRel != newRel {
+		if err := w.MoveFile(oldRel, newRel); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	note.VaultPath = newRel
+	return nil
+}
+
 // DeleteNote removes a note file from the Obsidian vault
 func (w *ObsidianWriter) DeleteNote(note *models.ProcessedNote) error {
-	filename := w.generateFilename(note)
-	filePath := filepath.Join(w.vaultPath, w.folderName, note.Category, filename)
-
-	// Prevent path traversal
-	if !strings.HasPrefix(filepath.Clean(filePath), filepath.Clean(w.vaultPath)) {
-		return fmt.Errorf("invalid file path: attempted path traversal")
+	filePath, err := w.resolve(w.currentPath(note))
+	if err != nil {
+		return err
 	}
 
-	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+	content, _ := os.ReadFile(filePath)
+	if err := os.Remove(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
+	w.recordChange(changeDelete, filePath, "", content)
 
 	return nil
 }
 
-// generateFilename creates a sanitized filename for the note
-func (w *ObsidianWriter) generateFilename(note *models.ProcessedNote) string {
-	// Format: YYYY-MM-DD-slugified-title.md
-	date := note.CreatedAt.Format("2006-01-02")
-	slug := slugify(note.Title)
-	return fmt.Sprintf("%s-%s.md", date, slug)
-}
+// WriteReview writes a periodic review to the Reviews folder of the vault
+func (w *ObsidianWriter) WriteReview(review *models.Review) error {
+	reviewPath := filepath.Join(w.vaultPath, w.folderName, "Reviews")
+	if err := w.mkdirAll(reviewPath); err != nil {
+		return fmt.Errorf("failed to create reviews folder: %w", err)
+	}
 
-// generateContent creates the full markdown content with Obsidian-compatible frontmatter
-func (w *ObsidianWriter) generateContent(note *models.ProcessedNote) string {
-	var sb strings.Builder
+	filePath := filepath.Join(reviewPath, review.ID+".md")
 
-	// Full Obsidian-compatible frontmatter
-	sb.WriteString("---\n")
+	// Prevent path traversal
+	if !strings.HasPrefix(filepath.Clean(filePath), filepath.Clean(w.vaultPath)) {
+		return fmt.Errorf("invalid file path: attempted path traversal")
+	}
 
-	// Core metadata
-	sb.WriteString(fmt.Sprintf("created: %s\n", note.CreatedAt.Format(time.RFC3339)))
-	sb.WriteString(fmt.Sprintf("modified: %s\n", time.Now().Format(time.RFC3339)))
-	sb.WriteString(fmt.Sprintf("category: %s\n", note.Category))
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	sb.WriteString(fmt.Sprintf("created: %s\n", review.CreatedAt.Format(time.RFC3339)))
+	sb.WriteString(fmt.Sprintf("period_start: %s\n", review.PeriodStart.Format("2006-01-02")))
+	sb.WriteString(fmt.Sprintf("period_end: %s\n", review.PeriodEnd.Format("2006-01-02")))
 	sb.WriteString("source: idea-forge\n")
-	sb.WriteString(fmt.Sprintf("id: %s\n", note.ID))
-
-	// Tags for Obsidian tag search (YAML array format)
 	sb.WriteString("tags:\n")
 	sb.WriteString("  - idea-forge\n")
-	sb.WriteString(fmt.Sprintf("  - %s\n", note.Category))
-	sb.WriteString("  - todo\n")
+	sb.WriteString("  - review\n")
+	sb.WriteString("---\n\n")
+	sb.WriteString(review.Markdown)
+	sb.WriteString(fmt.Sprintf("\n\n---\n*Generated by [[IdeaForge]] on %s*\n", review.CreatedAt.Format("2006-01-02")))
 
-	// Aliases for search/linking
-	sb.WriteString("aliases:\n")
-	sb.WriteString(fmt.Sprintf("  - \"%s\"\n", note.Title))
-	// Add slug as alias for easier linking
-	slug := slugify(note.Title)
-	if slug != strings.ToLower(note.Title) {
-		sb.WriteString(fmt.Sprintf("  - \"%s\"\n", slug))
+	if err := w.writeFile(filePath, []byte(sb.String())); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	// Status for task tracking
-	sb.WriteString("status: active\n")
-
-	sb.WriteString("---\n\n")
+	return nil
+}
 
-	// Main content from LLM
-	sb.WriteString(note.Markdown)
-
-	// Resources section with Obsidian-style links
-	if len(note.Links) > 0 {
-		sb.WriteString("\n\n## Resources\n\n")
-		for _, link := range note.Links {
-			// Use Obsidian external link format
-			if link.Description != "" {
-				sb.WriteString(fmt.Sprintf("- [%s](%s) - %s\n", link.Title, link.URL, link.Description))
-			} else {
-				sb.WriteString(fmt.Sprintf("- [%s](%s)\n", link.Title, link.URL))
-			}
+// generateContent renders the full markdown file of a note from its category's template
+func (w *ObsidianWriter) generateContent(note *models.ProcessedNote) (string, error) {
+	data := noteTemplateData{
+		ProcessedNote: *note,
+		Slug:          slugify(note.Title),
+		Filename:      w.noteFilename(note),
+		Tasks:         models.ExtractTasks(note.Markdown),
+		Dataview:      w.tasks.dataview,
+		Project:       note.Title,
+	}
+	summary := summarizeTasks(note)
+	data.Progress, data.Priority, data.Effort = summary.Progress, summary.Priority, summary.Effort
+	data.Markdown = w.tasks.formatTasks(note)
+	if w.related != nil {
+		for _, related := range w.related(note) {
+			data.Related = append(data.Related, noteLink{Filename: w.noteFilename(&related), Title: related.Title})
 		}
 	}
+	if w.attachments != nil {
+		for _, a := range w.attachments(note) {
+			data.Attachments = append(data.Attachments, attachmentVaultName(&a))
+		}
+	}
+	if data.Status == "" {
+		data.Status = models.StatusActive
+	}
+
+	var sb strings.Builder
+	if err := w.templates.forCategory(note.Category).Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render note template: %w", err)
+	}
 
-	// Footer with metadata for graph view connections
-	sb.WriteString("\n\n---\n")
-	sb.WriteString(fmt.Sprintf("*Original note: \"%s\"*\n", note.Original))
-	sb.WriteString(fmt.Sprintf("*Generated by [[IdeaForge]] on %s*\n", note.CreatedAt.Format("2006-01-02")))
+	content := sb.String()
+	if front, _, ok := splitFrontmatter(content); ok {
+		if _, err := parseFrontmatter(front); err != nil {
+			return "", fmt.Errorf("note template produced %w", err)
+		}
+	}
 
-	return sb.String()
+	return content, nil
 }
 
-// slugify converts a title to a URL-safe slug
+// slugify converts a title to a filename-safe slug, transliterating non-ASCII letters
 func slugify(title string) string {
+	return asciiSlug(transliterate(title))
+}
+
+// asciiSlug lowercases text and keeps only ASCII letters, digits and single hyphens
+func asciiSlug(title string) string {
 	// Convert to lowercase
 	slug := strings.ToLower(title)
 
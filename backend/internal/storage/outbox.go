@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// EnqueueVaultOp adds an operation to the vault outbox, due immediately.
+// A write is skipped when the note already has a write pending, since both write the same state.
+func (d *Database) EnqueueVaultOp(op *models.VaultOp) error {
+	if op.Op == models.VaultOpWrite {
+		var pending int
+		if err := d.db.QueryRow(
+			"SELECT COUNT(*) FROM vault_outbox WHERE note_id = ? AND op = ?", op.NoteID, models.VaultOpWrite,
+		).Scan(&pending); err != nil {
+			return fmt.Errorf("failed to check vault outbox: %w", err)
+		}
+		if pending > 0 {
+			return nil
+		}
+	}
+
+	var previous sql.NullString
+	if op.Previous != nil {
+		data, err := json.Marshal(op.Previous)
+		if err != nil {
+			return fmt.Errorf("failed to marshal previous note: %w", err)
+		}
+		previous = sql.NullString{String: string(data), Valid: true}
+	}
+
+	now := time.Now()
+	op.CreatedAt = now
+	op.NextAttemptAt = now
+
+	result, err := d.db.Exec(`
+		INSERT INTO vault_outbox (note_id, op, previous, attempts, last_error, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, op.NoteID, op.Op, previous, op.Attempts, op.LastError, op.NextAttemptAt, op.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue vault operation: %w", err)
+	}
+
+	op.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// PendingVaultOps returns queued vault operations in the order they were added
+func (d *Database) PendingVaultOps(limit int) ([]models.VaultOp, error) {
+	rows, err := d.db.Query(`
+		SELECT `+vaultOpColumns+` FROM vault_outbox ORDER BY id LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vault outbox: %w", err)
+	}
+	defer rows.Close()
+
+	ops := make([]models.VaultOp, 0)
+	for rows.Next() {
+		op, err := scanVaultOp(rows)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, *op)
+	}
+
+	return ops, rows.Err()
+}
+
+// CompleteVaultOp removes a finished operation from the outbox
+func (d *Database) CompleteVaultOp(id int64) error {
+	if _, err := d.db.Exec("DELETE FROM vault_outbox WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to complete vault operation: %w", err)
+	}
+	return nil
+}
+
+// RetryVaultOp records a failed attempt and when to try again
+func (d *Database) RetryVaultOp(id int64, lastErr error, next time.Time) error {
+	_, err := d.db.Exec(`
+		UPDATE vault_outbox SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+		WHERE id = ?
+	`, lastErr.Error(), next, id)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule vault operation: %w", err)
+	}
+	return nil
+}
+
+// OutboxStats counts pending vault operations and returns the oldest one
+func (d *Database) OutboxStats() (*models.OutboxStats, error) {
+	var stats models.OutboxStats
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM vault_outbox").Scan(&stats.Pending); err != nil {
+		return nil, fmt.Errorf("failed to count vault outbox: %w", err)
+	}
+
+	if stats.Pending == 0 {
+		return &stats, nil
+	}
+
+	row := d.db.QueryRow("SELECT " + vaultOpColumns + " FROM vault_outbox ORDER BY id LIMIT 1")
+	oldest, err := scanVaultOp(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oldest vault operation: %w", err)
+	}
+	stats.Oldest = oldest
+
+	return &stats, nil
+}
+
+// vaultOpColumns is the column list used by queries that return outbox operations
+const vaultOpColumns = "id, note_id, op, previous, attempts, last_error, next_attempt_at, created_at"
+
+// scanVaultOp reads an operation selected with vaultOpColumns
+func scanVaultOp(row rowScanner) (*models.VaultOp, error) {
+	var op models.VaultOp
+	var previous sql.NullString
+
+	if err := row.Scan(&op.ID, &op.NoteID, &op.Op, &previous, &op.Attempts, &op.LastError,
+		&op.NextAttemptAt, &op.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan vault operation: %w", err)
+	}
+
+	if previous.Valid {
+		op.Previous = &models.ProcessedNote{}
+		if err := json.Unmarshal([]byte(previous.String), op.Previous); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal previous note: %w", err)
+		}
+	}
+
+	return &op, nil
+}
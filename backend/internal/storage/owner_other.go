@@ -0,0 +1,10 @@
+//go:build !unix
+
+package storage
+
+import "io/fs"
+
+// statOwner is not supported without unix file ownership
+func statOwner(info fs.FileInfo) (uid, gid int, ok bool) {
+	return -1, -1, false
+}
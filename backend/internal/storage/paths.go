@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// maxFilenameSuffix bounds the search for a free "-N" filename suffix
+const maxFilenameSuffix = 1000
+
+// suffixPattern matches the "-N" suffix added to de-duplicate filenames
+var suffixPattern = regexp.MustCompile(`^-\d+$`)
+
+// noteStem is the preferred filename of a note without extension: YYYY-MM-DD-slugified-title.
+// Titles without a usable slug (emoji, scripts that cannot be transliterated) fall back to the note id.
+func noteStem(note *models.ProcessedNote) string {
+	slug := slugify(note.Title)
+	if slug == "" {
+		slug = "note"
+		if id := strings.TrimPrefix(note.ID, "note_"); id != "" {
+			slug += "-" + slugify(id)
+		}
+	}
+	return note.CreatedAt.Format("2006-01-02") + "-" + slug
+}
+
+// legacyFilename is the filename notes were written under before paths were stored
+func legacyFilename(note *models.ProcessedNote) string {
+	return note.CreatedAt.Format("2006-01-02") + "-" + asciiSlug(note.Title) + ".md"
+}
+
+// currentPath returns where the note's file was last written, relative to the vault root.
+// Notes without a stored path are looked for under the name they were originally given.
+func (w *ObsidianWriter) currentPath(note *models.ProcessedNote) string {
+	if note.VaultPath != "" {
+		return note.VaultPath
+	}
+	return path.Join(filepath.ToSlash(w.folderName), note.Category, legacyFilename(note))
+}
+
+// resolvePath picks the file a note should be written to, relative to the vault root.
+// The current file is kept while it still fits the note's title and category, or when it was
+// adopted outside the IdeaForge folder by an import; otherwise the
+// preferred name is used, with a "-2", "-3", ... suffix if another note's file already has it.
+func (w *ObsidianWriter) resolvePath(note *models.ProcessedNote) (string, error) {
+	dir := path.Join(filepath.ToSlash(w.folderName), note.Category)
+	stem := noteStem(note)
+
+	current := w.currentPath(note)
+
+	// Files adopted by an import stay where the user keeps them
+	if note.VaultPath != "" && !w.managed(current) {
+		if owner, exists := w.fileOwner(current); exists && owner == note.ID {
+			return current, nil
+		}
+	}
+
+	if path.Dir(current) == dir {
+		owner, exists := w.fileOwner(current)
+		ours := !exists || owner == note.ID
+		switch {
+		case note.VaultPath != "" && ours && matchesStem(path.Base(current), stem):
+			return current, nil
+		case note.VaultPath == "" && exists && owner == note.ID:
+			// Written before paths were stored; keep its name
+			return current, nil
+		}
+	}
+
+	for i := 1; i <= maxFilenameSuffix; i++ {
+		name := stem + ".md"
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d.md", stem, i)
+		}
+
+		candidate := path.Join(dir, name)
+		if owner, exists := w.fileOwner(candidate); !exists || owner == note.ID {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free filename for %s in %s", stem, dir)
+}
+
+// matchesStem reports whether a filename is the stem itself or the stem with a "-N" suffix
+func matchesStem(filename, stem string) bool {
+	name := strings.TrimSuffix(filename, ".md")
+	if name == stem {
+		return true
+	}
+	return strings.HasPrefix(name, stem) && suffixPattern.MatchString(name[len(stem):])
+}
+
+// fileOwner returns the frontmatter id of a vault file and whether the file exists
+func (w *ObsidianWriter) fileOwner(rel string) (string, bool) {
+	full, err := w.resolve(rel)
+	if err != nil {
+		return "", false
+	}
+
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return "", !os.IsNotExist(err)
+	}
+
+	front, _, ok := splitFrontmatter(string(data))
+	if !ok {
+		return "", true
+	}
+	fields, err := parseFrontmatter(front)
+	if err != nil {
+		return "", true
+	}
+
+	return frontmatterString(fields, "id"), true
+}
+
+// noteFilename is the name wikilinks use for a note: its filename without extension
+func (w *ObsidianWriter) noteFilename(note *models.ProcessedNote) string {
+	return strings.TrimSuffix(path.Base(w.currentPath(note)), ".md")
+}
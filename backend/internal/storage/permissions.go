@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+const (
+	defaultFileMode fs.FileMode = 0644
+	defaultDirMode  fs.FileMode = 0755
+
+	probePrefix = ".ideaforge-probe-"
+)
+
+// vaultPermissions controls the mode and owner of files and folders written to the vault.
+// Syncthing on the host only syncs files it can read and replace, so both matter.
+type vaultPermissions struct {
+	fileMode fs.FileMode
+	dirMode  fs.FileMode
+	uid      int // -1 when PUID is not set
+	gid      int // -1 when PGID is not set
+
+	// chownDenied is set once a chown failed for lack of privileges, after which
+	// files keep the process owner
+	chownDenied atomic.Bool
+}
+
+// loadVaultPermissions reads OBSIDIAN_FILE_MODE, OBSIDIAN_DIR_MODE, PUID and PGID
+func loadVaultPermissions() (*vaultPermissions, error) {
+	fileMode, err := parseMode("OBSIDIAN_FILE_MODE", defaultFileMode)
+	if err != nil {
+		return nil, err
+	}
+	dirMode, err := parseMode("OBSIDIAN_DIR_MODE", defaultDirMode)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := parseID("PUID")
+	if err != nil {
+		return nil, err
+	}
+	gid, err := parseID("PGID")
+	if err != nil {
+		return nil, err
+	}
+
+	return &vaultPermissions{
+		fileMode: fileMode,
+		dirMode:  dirMode,
+		uid:      uid,
+		gid:      gid,
+	}, nil
+}
+
+// parseMode reads an octal permission mode such as 0664 from the environment
+func parseMode(key string, fallback fs.FileMode) (fs.FileMode, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("%s must be an octal permission mode such as 0644, got %q", key, value)
+	}
+
+	return fs.FileMode(mode), nil
+}
+
+// parseID reads a numeric user or group id from the environment, -1 when unset
+func parseID(key string) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return -1, nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("%s must be a numeric id, got %q", key, value)
+	}
+
+	return id, nil
+}
+
+// wantsChown reports whether written files should be handed to another owner.
+// Running as PUID:PGID already (the Docker default) needs no chown.
+func (p *vaultPermissions) wantsChown() bool {
+	if p.chownDenied.Load() {
+		return false
+	}
+	return (p.uid >= 0 && p.uid != os.Getuid()) || (p.gid >= 0 && p.gid != os.Getgid())
+}
+
+// chown hands a written file or folder to PUID:PGID. Lacking the privilege to do so is
+// logged once and otherwise ignored, so notes are still written.
+func (p *vaultPermissions) chown(path string) error {
+	if !p.wantsChown() {
+		return nil
+	}
+
+	if err := os.Lchown(path, p.uid, p.gid); err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			if !p.chownDenied.Swap(true) {
+				log.Printf("Warning: Cannot change vault file ownership to %s (needs root or CAP_CHOWN); files keep uid %d",
+					formatOwner(p.uid, p.gid), os.Getuid())
+			}
+			return nil
+		}
+		return fmt.Errorf("failed to change owner: %w", err)
+	}
+
+	return nil
+}
+
+// writeFile writes a file atomically with the configured mode and owner
+func (p *vaultPermissions) writeFile(path string, data []byte) error {
+	if err := writeFileAtomic(path, data, p.fileMode); err != nil {
+		return err
+	}
+	return p.chown(path)
+}
+
+// mkdirAll creates a folder and its missing parents with the configured mode and owner.
+// Modes are set explicitly since os.MkdirAll is subject to the umask.
+func (p *vaultPermissions) mkdirAll(dir string) error {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(dir, p.dirMode); err != nil {
+		return err
+	}
+
+	for _, d := range missing {
+		if err := os.Chmod(d, p.dirMode); err != nil {
+			return fmt.Errorf("failed to set folder mode: %w", err)
+		}
+		if err := p.chown(d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeFile writes a vault file atomically with the configured mode and owner
+func (w *ObsidianWriter) writeFile(path string, data []byte) error {
+	action := existsAction(path)
+	if err := w.perms.writeFile(path, data); err != nil {
+		return err
+	}
+	w.recordChange(action, path, "", data)
+	return nil
+}
+
+// mkdirAll creates a vault folder and its missing parents with the configured mode and owner
+func (w *ObsidianWriter) mkdirAll(dir string) error {
+	return w.perms.mkdirAll(dir)
+}
+
+// vaultProbe is the outcome of the startup self-check
+type vaultProbe struct {
+	err      error
+	uid, gid int // owner the probe file ended up with, -1 if unknown
+}
+
+// selfCheck writes, reads back and deletes a probe file in the IdeaForge folder, so
+// permission problems show up at startup rather than on the first captured note
+func (w *ObsidianWriter) selfCheck() vaultProbe {
+	probe := vaultProbe{uid: -1, gid: -1}
+
+	dir := filepath.Join(w.vaultPath, w.folderName)
+	if err := w.mkdirAll(dir); err != nil {
+		probe.err = fmt.Errorf("failed to create folder: %w", err)
+		return probe
+	}
+
+	path := filepath.Join(dir, probePrefix+strconv.Itoa(os.Getpid()))
+	data := []byte("idea-forge vault self-check\n")
+
+	if err := w.writeFile(path, data); err != nil {
+		probe.err = fmt.Errorf("failed to write probe file: %w", err)
+		return probe
+	}
+	defer os.Remove(path)
+
+	if info, err := os.Lstat(path); err == nil {
+		probe.uid, probe.gid, _ = statOwner(info)
+	}
+
+	read, err := os.ReadFile(path)
+	if err != nil {
+		probe.err = fmt.Errorf("failed to read probe file: %w", err)
+		return probe
+	}
+	if !bytes.Equal(read, data) {
+		probe.err = fmt.Errorf("probe file read back different content")
+		return probe
+	}
+
+	if err := os.Remove(path); err != nil {
+		probe.err = fmt.Errorf("failed to delete probe file: %w", err)
+		return probe
+	}
+
+	return probe
+}
+
+// Health reports the startup self-check and whether files end up with the expected owner.
+// The expected owner is PUID:PGID, or the owner of the vault root (usually the Syncthing
+// user) when those aren't set.
+func (w *ObsidianWriter) Health() models.VaultHealth {
+	health := models.VaultHealth{
+		Name:     w.name,
+		Path:     w.vaultPath,
+		Folder:   w.folderName,
+		Writable: w.probe.err == nil,
+		FileMode: fmt.Sprintf("%04o", w.perms.fileMode),
+		DirMode:  fmt.Sprintf("%04o", w.perms.dirMode),
+		Chown:    w.perms.wantsChown(),
+	}
+	if w.probe.err != nil {
+		health.ProbeError = w.probe.err.Error()
+	}
+
+	uid, gid := w.perms.uid, w.perms.gid
+	if info, err := os.Stat(w.vaultPath); err == nil {
+		if rootUID, rootGID, ok := statOwner(info); ok {
+			if uid < 0 {
+				uid = rootUID
+			}
+			if gid < 0 {
+				gid = rootGID
+			}
+		}
+	}
+	if uid < 0 || gid < 0 {
+		// Ownership isn't available on this platform
+		return health
+	}
+	health.ExpectedOwner = formatOwner(uid, gid)
+
+	if w.probe.uid >= 0 {
+		health.FileOwner = formatOwner(w.probe.uid, w.probe.gid)
+		health.OwnerMismatch = w.probe.uid != uid || w.probe.gid != gid
+	}
+
+	if info, err := os.Stat(filepath.Join(w.vaultPath, w.folderName)); err == nil {
+		if folderUID, folderGID, ok := statOwner(info); ok {
+			health.FolderOwner = formatOwner(folderUID, folderGID)
+			if folderUID != uid || folderGID != gid {
+				health.OwnerMismatch = true
+			}
+		}
+	}
+
+	return health
+}
+
+// formatOwner renders an owner as uid:gid
+func formatOwner(uid, gid int) string {
+	return fmt.Sprintf("%d:%d", uid, gid)
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// SaveReview stores a review, replacing an earlier one for the same period
+func (d *Database) SaveReview(review *models.Review) error {
+	_, err := d.db.Exec(`
+		INSERT INTO reviews (id, period_start, period_end, markdown, created_at, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			period_start = excluded.period_start,
+			period_end = excluded.period_end,
+			markdown = excluded.markdown,
+			created_at = excluded.created_at,
+			synced_at = excluded.synced_at
+	`, review.ID, review.PeriodStart, review.PeriodEnd, review.Markdown, review.CreatedAt, review.SyncedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save review: %w", err)
+	}
+	return nil
+}
+
+// ListReviews returns stored reviews, newest first
+func (d *Database) ListReviews(limit, offset int) ([]models.Review, int, error) {
+	var total int
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM reviews").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count reviews: %w", err)
+	}
+
+	rows, err := d.db.Query(`
+		SELECT id, period_start, period_end, markdown, created_at, synced_at
+		FROM reviews
+		ORDER BY period_start DESC
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := make([]models.Review, 0)
+	for rows.Next() {
+		var review models.Review
+		var syncedAt sql.NullTime
+		if err := rows.Scan(&review.ID, &review.PeriodStart, &review.PeriodEnd, &review.Markdown, &review.CreatedAt, &syncedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan review: %w", err)
+		}
+		if syncedAt.Valid {
+			review.SyncedAt = &syncedAt.Time
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, total, rows.Err()
+}
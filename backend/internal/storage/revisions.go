@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// insertRevision appends a snapshot of the note to its revision history
+func insertRevision(tx *sql.Tx, note *models.ProcessedNote, linksJSON, tasksJSON, source string) error {
+	var next int
+	if err := tx.QueryRow(
+		"SELECT COALESCE(MAX(revision), 0) + 1 FROM note_revisions WHERE note_id = ?", note.ID,
+	).Scan(&next); err != nil {
+		return fmt.Errorf("failed to determine revision number: %w", err)
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO note_revisions (note_id, revision, title, category, markdown, links, task_details, source, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, note.ID, next, note.Title, note.Category, note.Markdown, linksJSON, tasksJSON, source, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to insert revision: %w", err)
+	}
+
+	return nil
+}
+
+// ListRevisions returns the revision history of a note, oldest first
+func (d *Database) ListRevisions(noteID string) ([]models.NoteRevision, error) {
+	rows, err := d.db.Query(`
+		SELECT id, note_id, revision, title, category, markdown, links, task_details, source, created_at
+		FROM note_revisions WHERE note_id = ?
+		ORDER BY revision ASC
+	`, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := make([]models.NoteRevision, 0)
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetRevision retrieves a single revision of a note, or nil if it does not exist
+func (d *Database) GetRevision(noteID string, revision int) (*models.NoteRevision, error) {
+	row := d.db.QueryRow(`
+		SELECT id, note_id, revision, title, category, markdown, links, task_details, source, created_at
+		FROM note_revisions WHERE note_id = ? AND revision = ?
+	`, noteID, revision)
+
+	rev, err := scanRevision(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rev, err
+}
+
+// LatestRevision returns the most recent revision number of a note, or 0 if it has none
+func (d *Database) LatestRevision(noteID string) (int, error) {
+	var latest int
+	err := d.db.QueryRow(
+		"SELECT COALESCE(MAX(revision), 0) FROM note_revisions WHERE note_id = ?", noteID,
+	).Scan(&latest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest revision: %w", err)
+	}
+	return latest, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanRevision reads a revision from a query result
+func scanRevision(row rowScanner) (*models.NoteRevision, error) {
+	var rev models.NoteRevision
+	var linksJSON, tasksJSON string
+
+	err := row.Scan(&rev.ID, &rev.NoteID, &rev.Revision, &rev.Title, &rev.Category, &rev.Markdown, &linksJSON, &tasksJSON, &rev.Source, &rev.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan revision: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(linksJSON), &rev.Links); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal links: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(tasksJSON), &rev.TaskDetails); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal task details: %w", err)
+	}
+
+	return &rev, nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+func TestRevisionsKeepTaskDetails(t *testing.T) {
+	t.Setenv("DATABASE_PATH", filepath.Join(t.TempDir(), "ideaforge.db"))
+	db, err := NewDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	first := []models.TaskDetails{{Text: "Buy disks", Due: "2026-10-20", Priority: "high"}}
+	note := &models.ProcessedNote{
+		Title: "Backup plan", Category: "homelab", Original: "backup plan", CreatedAt: time.Now(),
+		Markdown: "- [ ] Buy disks", TaskDetails: first,
+	}
+	if err := db.CreateNote(note, models.RevisionSourceAPI); err != nil {
+		t.Fatal(err)
+	}
+
+	note.Markdown = "- [ ] Test restore"
+	note.TaskDetails = []models.TaskDetails{{Text: "Test restore", Due: "2026-11-01"}}
+	if err := db.UpdateNote(note, models.RevisionSourceAPI); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := db.ListRevisions(note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("revisions = %d, want 2", len(revisions))
+	}
+	if !reflect.DeepEqual(revisions[0].TaskDetails, first) {
+		t.Errorf("revision 1 task details = %+v, want %+v", revisions[0].TaskDetails, first)
+	}
+	if !reflect.DeepEqual(revisions[1].TaskDetails, note.TaskDetails) {
+		t.Errorf("revision 2 task details = %+v, want %+v", revisions[1].TaskDetails, note.TaskDetails)
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kilo40/idea-forge/internal/models"
+	"github.com/kilo40/idea-forge/internal/similarity"
+)
+
+// Keywords splits text into the distinct search terms of the similarity index
+func Keywords(text string) []string {
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, term := range similarity.Tokenize(text) {
+		if !seen[term] {
+			seen[term] = true
+			keywords = append(keywords, term)
+		}
+	}
+	return keywords
+}
+
+// SearchNotes returns the notes that best match the keywords of a query, most relevant first.
+// Title matches weigh more than matches in the body.
+func (d *Database) SearchNotes(query string, limit int) ([]models.ProcessedNote, error) {
+	keywords := Keywords(query)
+	if len(keywords) == 0 {
+		return []models.ProcessedNote{}, nil
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, keyword := range keywords {
+		conditions = append(conditions, "(LOWER(title) LIKE ? OR LOWER(markdown) LIKE ?)")
+		pattern := "%" + keyword + "%"
+		args = append(args, pattern, pattern)
+	}
+
+	rows, err := d.db.Query("SELECT "+noteColumns+" FROM notes WHERE "+strings.Join(conditions, " OR "), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search notes: %w", err)
+	}
+	defer rows.Close()
+
+	type scored struct {
+		note  models.ProcessedNote
+		score int
+	}
+
+	var results []scored
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+
+		title := strings.ToLower(note.Title)
+		body := strings.ToLower(note.Markdown)
+		score := 0
+		for _, keyword := range keywords {
+			if strings.Contains(title, keyword) {
+				score += 3
+			}
+			score += min(strings.Count(body, keyword), 3)
+		}
+		results = append(results, scored{note: *note, score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	notes := make([]models.ProcessedNote, 0, min(limit, len(results)))
+	for i := 0; i < len(results) && i < limit; i++ {
+		notes = append(notes, results[i].note)
+	}
+	return notes, nil
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// Sink is a destination notes are mirrored to after they are saved in the database.
+// MoveNote is called before WriteNote when a note's title or category changed.
+type Sink interface {
+	Name() string
+	WriteNote(note *models.ProcessedNote) error
+	MoveNote(previous, note *models.ProcessedNote) error
+	DeleteNote(note *models.ProcessedNote) error
+}
+
+// The Obsidian vault is a sink too, though the server drives it directly for its
+// outbox, resync and conflict handling
+var _ Sink = (*ObsidianWriter)(nil)
+
+// Name identifies the sink in logs and /health
+func (w *ObsidianWriter) Name() string {
+	return "obsidian"
+}
+
+// ConfiguredSinks returns the sinks other than Obsidian that are enabled in the environment:
+// LOGSEQ_GRAPH_PATH, MARKDOWN_SINK_PATH and ORG_SINK_PATH. Misconfigured sinks are logged and skipped.
+func ConfiguredSinks() []Sink {
+	perms, err := loadVaultPermissions()
+	if err != nil {
+		log.Printf("Warning: Sinks disabled: %v", err)
+		return nil
+	}
+
+	constructors := []struct {
+		env string
+		new func(root string, perms *vaultPermissions) Sink
+	}{
+		{"LOGSEQ_GRAPH_PATH", newLogseqSink},
+		{"MARKDOWN_SINK_PATH", newMarkdownSink},
+		{"ORG_SINK_PATH", newOrgSink},
+	}
+
+	var sinks []Sink
+	for _, c := range constructors {
+		root := os.Getenv(c.env)
+		if root == "" {
+			continue
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			log.Printf("Warning: Sink disabled, %s is not a directory: %s", c.env, root)
+			continue
+		}
+
+		sink := c.new(root, perms)
+		log.Printf("Sink enabled: %s at %s", sink.Name(), root)
+		sinks = append(sinks, sink)
+	}
+
+	return sinks
+}
+
+// folderSink writes one file per note to <root>/<category>/<date>-<slug>-<id><ext>, or directly
+// below the root when flat. Unlike the Obsidian vault these folders are write-only mirrors,
+// so a file's location is derived from the note each time rather than stored; the short note
+// id keeps notes with the same title and date apart.
+type folderSink struct {
+	root  string
+	ext   string
+	flat  bool
+	perms *vaultPermissions
+}
+
+// path returns the absolute file path of a note
+func (f *folderSink) path(note *models.ProcessedNote) (string, error) {
+	dir := f.root
+	if !f.flat {
+		dir = filepath.Join(f.root, slugify(note.Category))
+	}
+	full := filepath.Join(dir, noteStem(note)+"-"+shortNoteID(note)+f.ext)
+
+	// Prevent path traversal
+	if !strings.HasPrefix(filepath.Clean(full), filepath.Clean(f.root)+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path: attempted path traversal")
+	}
+
+	return full, nil
+}
+
+// shortNoteID is a note id without its "note_" prefix, made filename-safe
+func shortNoteID(note *models.ProcessedNote) string {
+	return slugify(strings.TrimPrefix(note.ID, "note_"))
+}
+
+// write stores the rendered content of a note
+func (f *folderSink) write(note *models.ProcessedNote, content string) error {
+	full, err := f.path(note)
+	if err != nil {
+		return err
+	}
+
+	if err := f.perms.mkdirAll(filepath.Dir(full)); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	if err := f.perms.writeFile(full, []byte(content)); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// move renames the file of a note whose title or category changed
+func (f *folderSink) move(previous, note *models.ProcessedNote) error {
+	from, err := f.path(previous)
+	if err != nil {
+		return err
+	}
+	to, err := f.path(note)
+	if err != nil {
+		return err
+	}
+
+	if from == to {
+		return nil
+	}
+
+	if err := f.perms.mkdirAll(filepath.Dir(to)); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+
+	return nil
+}
+
+// remove deletes the file of a note; a file that is already gone is not an error
+func (f *folderSink) remove(note *models.ProcessedNote) error {
+	full, err := f.path(note)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return nil
+}
+
+// Markdown structure recognized when converting notes to outline and org formats
+var (
+	sinkHeadingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*$`)
+	sinkListItemPattern = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	sinkFencePattern    = regexp.MustCompile("^\\s*(```|~~~)\\s*([\\w+-]*)")
+	sinkRulePattern     = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+)
+
+// listNesting tracks the indentation of nested markdown list items
+type listNesting struct {
+	indents []int
+}
+
+// level returns the nesting depth of a list item indented by the given number of columns
+func (n *listNesting) level(indent int) int {
+	for len(n.indents) > 0 && n.indents[len(n.indents)-1] > indent {
+		n.indents = n.indents[:len(n.indents)-1]
+	}
+	if len(n.indents) == 0 || n.indents[len(n.indents)-1] < indent {
+		n.indents = append(n.indents, indent)
+	}
+	return len(n.indents) - 1
+}
+
+// reset forgets the enclosing list, e.g. after a heading
+func (n *listNesting) reset() {
+	n.indents = n.indents[:0]
+}
+
+// leadingWhitespace returns the indentation of a line
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// indentWidth counts the columns of leading whitespace, with tabs as four columns
+func indentWidth(whitespace string) int {
+	width := 0
+	for _, r := range whitespace {
+		if r == '\t' {
+			width += 4
+		} else {
+			width++
+		}
+	}
+	return width
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenNote has a bit of every kind of markdown the sinks convert
+func goldenNote() *models.ProcessedNote {
+	return &models.ProcessedNote{
+		ID:        "note_5a1e0c42",
+		Title:     "Rebuild the homelab rack",
+		Category:  "homelab",
+		Status:    models.StatusActive,
+		CreatedAt: time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC),
+		Original:  "rebuild the rack\n* new cabling, maybe 10G",
+		Markdown: "# Rebuild the homelab rack\n\n" +
+			"Move everything to the **new** rack and use `k3s` for the _services_.\n" +
+			"See [the guide](https://example.com/rack) and [[Cabling|the cabling plan]].\n\n" +
+			"## Tasks\n\n" +
+			"- [ ] Order the rack\n" +
+			"  - [ ] Compare prices\n" +
+			"  - [x] Measure the closet\n" +
+			"- [ ] Install k3s\n" +
+			"- [x] Back up the NAS\n" +
+			"- [ ] Rotate backups\n\n" +
+			"## Notes\n\n" +
+			"* Keep the old switch\n" +
+			"  + as a spare\n" +
+			"1. Label the cables\n\n" +
+			"> Measure twice,\n> cut once\n\n" +
+			"---\n\n" +
+			"```bash\n# not a heading\nkubectl get nodes\n```",
+		TaskDetails: []models.TaskDetails{
+			{Text: "Order the rack", Due: "2026-10-20", Priority: "high", Effort: 1.5},
+			{Text: "Compare prices", Priority: "low"},
+			{Text: "Install k3s", Priority: "medium", Effort: 0.25},
+			{Text: "Rotate backups", Due: "2026-11-02", Recurrence: "every 2 weeks", Priority: "highest"},
+		},
+		Links: []models.Link{
+			{Title: "Rack guide", URL: "https://example.com/rack", Description: "sizing and airflow"},
+		},
+	}
+}
+
+// checkGolden compares output with a file in testdata, or rewrites it with -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the golden file, got:\n%s", name, got)
+	}
+}
+
+func TestRenderOrgGolden(t *testing.T) {
+	checkGolden(t, "note.org", renderOrg(goldenNote()))
+}
+
+func TestRenderLogseqGolden(t *testing.T) {
+	checkGolden(t, "note.logseq.md", renderLogseqPage(goldenNote(), "Rebuild the homelab rack"))
+}
@@ -0,0 +1,361 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+const (
+	// logseqJournalFileLayout is Logseq's default :journal/file-name-format, yyyy_MM_dd
+	logseqJournalFileLayout = "2006_01_02"
+
+	// logseqJournalTag marks the journal blocks written by IdeaForge
+	logseqJournalTag = "#idea-forge"
+)
+
+// LogseqSink writes notes as pages of a Logseq graph, in Logseq's outline format, and
+// links each page from the journal of the day the note was captured
+type LogseqSink struct {
+	pages     folderSink
+	journals  string
+	perms     *vaultPermissions
+	journalMu sync.Mutex
+}
+
+func newLogseqSink(root string, perms *vaultPermissions) Sink {
+	return &LogseqSink{
+		pages:    folderSink{root: filepath.Join(root, "pages"), ext: ".md", flat: true, perms: perms},
+		journals: filepath.Join(root, "journals"),
+		perms:    perms,
+	}
+}
+
+// Name identifies the sink in logs and /health
+func (s *LogseqSink) Name() string {
+	return "logseq"
+}
+
+// WriteNote writes a note's page and makes sure the journal links to it
+func (s *LogseqSink) WriteNote(note *models.ProcessedNote) error {
+	title := s.pageTitle(note)
+	if err := s.pages.write(note, renderLogseqPage(note, title)); err != nil {
+		return err
+	}
+
+	return s.updateJournal(note.CreatedAt, func(lines []string) []string {
+		if journalEntry(lines, title) >= 0 {
+			return lines
+		}
+		return append(lines, logseqJournalLine(note, title))
+	})
+}
+
+// MoveNote renames a note's page and updates the journal link after a title change
+func (s *LogseqSink) MoveNote(previous, note *models.ProcessedNote) error {
+	previousTitle := s.currentTitle(previous)
+	if err := s.pages.move(previous, note); err != nil {
+		return err
+	}
+
+	title := s.pageTitle(note)
+	if previousTitle == title {
+		return nil
+	}
+
+	return s.updateJournal(note.CreatedAt, func(lines []string) []string {
+		if i := journalEntry(lines, previousTitle); i >= 0 {
+			lines[i] = logseqJournalLine(note, title)
+		}
+		return lines
+	})
+}
+
+// DeleteNote removes a note's page and its journal link
+func (s *LogseqSink) DeleteNote(note *models.ProcessedNote) error {
+	title := s.currentTitle(note)
+	if err := s.pages.remove(note); err != nil {
+		return err
+	}
+
+	return s.updateJournal(note.CreatedAt, func(lines []string) []string {
+		if i := journalEntry(lines, title); i >= 0 {
+			lines = append(lines[:i], lines[i+1:]...)
+		}
+		return lines
+	})
+}
+
+// pageTitle is the Logseq page name of a note: its title, or the title with the short note id
+// when another IdeaForge page already uses that name, since Logseq merges pages by name.
+// A page keeps the name it was given while the title doesn't change.
+func (s *LogseqSink) pageTitle(note *models.ProcessedNote) string {
+	disambiguated := fmt.Sprintf("%s (%s)", note.Title, shortNoteID(note))
+	own, err := s.pages.path(note)
+	if err != nil {
+		return note.Title
+	}
+	if current, _ := logseqPageProperties(own); current == note.Title || current == disambiguated {
+		return current
+	}
+
+	entries, err := os.ReadDir(s.pages.root)
+	if err != nil {
+		return note.Title
+	}
+	for _, entry := range entries {
+		full := filepath.Join(s.pages.root, entry.Name())
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" || full == own {
+			continue
+		}
+		title, id := logseqPageProperties(full)
+		if id != "" && id != note.ID && strings.EqualFold(title, note.Title) {
+			return disambiguated
+		}
+	}
+	return note.Title
+}
+
+// currentTitle is the page name a note's page was last written with, or its title
+func (s *LogseqSink) currentTitle(note *models.ProcessedNote) string {
+	full, err := s.pages.path(note)
+	if err != nil {
+		return note.Title
+	}
+	if title, _ := logseqPageProperties(full); title != "" {
+		return title
+	}
+	return note.Title
+}
+
+// logseqPageProperties reads the title and IdeaForge id from the properties of a page
+func logseqPageProperties(full string) (title, id string) {
+	file, err := os.Open(full)
+	if err != nil {
+		return "", ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			break // the page properties end at the first blank line
+		}
+		if value, ok := strings.CutPrefix(line, "title:: "); ok {
+			title = value
+		} else if value, ok := strings.CutPrefix(line, "ideaforge-id:: "); ok {
+			id = value
+		}
+	}
+	return title, id
+}
+
+// updateJournal edits the journal page of a day. The file is only written when the
+// edit changed it, and not created for an edit that leaves it empty.
+func (s *LogseqSink) updateJournal(day time.Time, edit func([]string) []string) error {
+	s.journalMu.Lock()
+	defer s.journalMu.Unlock()
+
+	path := filepath.Join(s.journals, day.Format(logseqJournalFileLayout)+".md")
+
+	var lines []string
+	existing, err := os.ReadFile(path)
+	if err == nil {
+		content := strings.TrimRight(string(existing), "\n")
+		if content != "" {
+			lines = strings.Split(content, "\n")
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	updated := strings.Join(edit(lines), "\n")
+	if updated != "" {
+		updated += "\n"
+	}
+	if updated == string(existing) || (existing == nil && updated == "") {
+		return nil
+	}
+
+	if err := s.perms.mkdirAll(s.journals); err != nil {
+		return fmt.Errorf("failed to create journals folder: %w", err)
+	}
+
+	if err := s.perms.writeFile(path, []byte(updated)); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	return nil
+}
+
+// journalEntry returns the index of the journal block linking to a page, or -1
+func journalEntry(lines []string, title string) int {
+	link := "[[" + title + "]]"
+	for i, line := range lines {
+		if strings.HasPrefix(line, "- ") && strings.Contains(line, link) && strings.Contains(line, logseqJournalTag) {
+			return i
+		}
+	}
+	return -1
+}
+
+// logseqJournalLine is the journal block linking to a note's page
+func logseqJournalLine(note *models.ProcessedNote, title string) string {
+	return fmt.Sprintf("- Captured [[%s]] %s #[[%s]]", title, logseqJournalTag, note.Category)
+}
+
+// logseqDate formats a date as a journal page title in Logseq's default "MMM do, yyyy" format
+func logseqDate(t time.Time) string {
+	day := t.Day()
+	suffix := "th"
+	if day%100 < 11 || day%100 > 13 {
+		switch day % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%s %d%s, %d", t.Format("Jan"), day, suffix, t.Year())
+}
+
+// renderLogseqPage renders a note as a Logseq page named title: page properties followed by blocks
+func renderLogseqPage(note *models.ProcessedNote, title string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("title:: %s\n", title))
+	sb.WriteString(fmt.Sprintf("category:: [[%s]]\n", note.Category))
+	sb.WriteString(fmt.Sprintf("tags:: idea-forge, %s\n", note.Category))
+	sb.WriteString(fmt.Sprintf("status:: %s\n", noteStatus(note)))
+	sb.WriteString(fmt.Sprintf("created:: [[%s]]\n", logseqDate(note.CreatedAt)))
+	sb.WriteString("source:: idea-forge\n")
+	sb.WriteString(fmt.Sprintf("ideaforge-id:: %s\n", note.ID))
+	sb.WriteString("\n")
+
+	for _, line := range markdownToOutline(note.Markdown, note.TaskDetails) {
+		sb.WriteString(line + "\n")
+	}
+
+	if len(note.Links) > 0 {
+		sb.WriteString("- ## Resources\n")
+		for _, link := range note.Links {
+			sb.WriteString(fmt.Sprintf("\t- [%s](%s)", link.Title, link.URL))
+			if link.Description != "" {
+				sb.WriteString(" - " + link.Description)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString("- ## Original note\n")
+	original := strings.Split(strings.TrimSpace(note.Original), "\n")
+	sb.WriteString("\t- > " + original[0] + "\n")
+	for _, line := range original[1:] {
+		sb.WriteString("\t  > " + line + "\n")
+	}
+
+	return sb.String()
+}
+
+// outlineWriter collects Logseq blocks: one "- " line per block, nested with tabs, with
+// continuation lines aligned below the block's text
+type outlineWriter struct {
+	lines []string
+	depth int
+}
+
+func (o *outlineWriter) block(depth int, text string) {
+	o.lines = append(o.lines, strings.Repeat("\t", depth)+"- "+text)
+	o.depth = depth
+}
+
+func (o *outlineWriter) continuation(text string) {
+	o.lines = append(o.lines, strings.Repeat("\t", o.depth)+"  "+text)
+}
+
+// markdownToOutline converts note markdown to Logseq blocks. Each heading starts a
+// top-level block with the section's paragraphs and lists nested below it, and checklist
+// items become TODO/DONE blocks with their priority and deadline.
+func markdownToOutline(markdown string, details []models.TaskDetails) []string {
+	var o outlineWriter
+	var nesting listNesting
+	base := 0 // depth of content below the current heading
+	fence, paragraph, listItem := false, false, false
+
+	for _, line := range strings.Split(markdown, "\n") {
+		if fence {
+			o.continuation(line)
+			if sinkFencePattern.MatchString(line) {
+				fence = false
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			paragraph = false
+
+		case sinkFencePattern.MatchString(line):
+			o.block(base, trimmed)
+			fence, paragraph, listItem = true, false, false
+
+		case sinkHeadingPattern.MatchString(line):
+			o.block(0, trimmed)
+			base = 1
+			nesting.reset()
+			paragraph, listItem = false, false
+
+		case sinkRulePattern.MatchString(line):
+			paragraph, listItem = false, false
+
+		case taskLinePattern.MatchString(line):
+			match := taskLinePattern.FindStringSubmatch(line)
+			text := strings.TrimSpace(match[3])
+			d := models.FindTaskDetails(details, text)
+			o.block(base+nesting.level(indentWidth(leadingWhitespace(line))), logseqTask(match[2] != " ", text, d))
+			if d != nil {
+				if timestamp := orgTimestamp(d.Due, d.Recurrence); timestamp != "" {
+					o.continuation("DEADLINE: " + timestamp)
+				}
+			}
+			paragraph, listItem = false, true
+
+		case sinkListItemPattern.MatchString(line):
+			match := sinkListItemPattern.FindStringSubmatch(line)
+			o.block(base+nesting.level(indentWidth(match[1])), match[3])
+			paragraph, listItem = false, true
+
+		case paragraph || (listItem && leadingWhitespace(line) != ""):
+			o.continuation(trimmed)
+
+		default:
+			nesting.reset()
+			o.block(base, trimmed)
+			paragraph, listItem = true, false
+		}
+	}
+
+	return o.lines
+}
+
+// logseqTask renders the text of a checklist block, e.g. "TODO [#A] Install k3s"
+func logseqTask(done bool, text string, details *models.TaskDetails) string {
+	marker := "TODO"
+	if done {
+		marker = "DONE"
+	}
+	if details != nil && orgPriorities[details.Priority] != "" {
+		marker += " [#" + orgPriorities[details.Priority] + "]"
+	}
+	return marker + " " + text
+}
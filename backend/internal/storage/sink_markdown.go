@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// MarkdownSink writes notes as plain CommonMark files, without frontmatter or wikilinks,
+// for editors and static site tools that don't know about Obsidian
+type MarkdownSink struct {
+	folderSink
+}
+
+func newMarkdownSink(root string, perms *vaultPermissions) Sink {
+	return &MarkdownSink{folderSink{root: root, ext: ".md", perms: perms}}
+}
+
+// Name identifies the sink in logs and /health
+func (s *MarkdownSink) Name() string {
+	return "markdown"
+}
+
+// WriteNote writes a note's file
+func (s *MarkdownSink) WriteNote(note *models.ProcessedNote) error {
+	return s.write(note, renderCommonMark(note))
+}
+
+// MoveNote renames a note's file after its title or category changed
+func (s *MarkdownSink) MoveNote(previous, note *models.ProcessedNote) error {
+	return s.move(previous, note)
+}
+
+// DeleteNote removes a note's file
+func (s *MarkdownSink) DeleteNote(note *models.ProcessedNote) error {
+	return s.remove(note)
+}
+
+// renderCommonMark renders a note with its metadata as a short block under the title
+func renderCommonMark(note *models.ProcessedNote) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("# %s\n\n", note.Title))
+	sb.WriteString(fmt.Sprintf("**Category:** %s\\\n", note.Category))
+	sb.WriteString(fmt.Sprintf("**Status:** %s\\\n", noteStatus(note)))
+	sb.WriteString(fmt.Sprintf("**Created:** %s\\\n", note.CreatedAt.Format("2006-01-02")))
+	sb.WriteString(fmt.Sprintf("**ID:** %s\n\n", note.ID))
+
+	sb.WriteString(strings.TrimRight(note.Markdown, "\n"))
+	sb.WriteString("\n")
+
+	if len(note.Links) > 0 {
+		sb.WriteString("\n## Resources\n\n")
+		for _, link := range note.Links {
+			sb.WriteString(fmt.Sprintf("- [%s](%s)", link.Title, link.URL))
+			if link.Description != "" {
+				sb.WriteString(" - " + link.Description)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString("\n## Original note\n\n")
+	for _, line := range strings.Split(strings.TrimSpace(note.Original), "\n") {
+		sb.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// orgPriorities maps task priorities to the A/B/C priority cookies of org-mode and Logseq
+var orgPriorities = map[string]string{
+	"highest": "A",
+	"high":    "A",
+	"medium":  "B",
+	"low":     "C",
+	"lowest":  "C",
+}
+
+// orgRecurrencePattern matches recurrences such as "every week" or "every 2 months"
+var orgRecurrencePattern = regexp.MustCompile(`^every\s+(?:(\d+)\s+)?(day|week|month|year)s?$`)
+
+// Inline markdown converted to org markup
+var (
+	orgCodeSpanPattern = regexp.MustCompile("`([^`]+)`")
+	orgLinkPattern     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	orgWikilinkPattern = regexp.MustCompile(`\[\[([^\]|]+)\|([^\]]+)\]\]`)
+	orgBoldPattern     = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	orgItalicPattern   = regexp.MustCompile(`\*([^*\s][^*]*?)\*|\b_([^_\s][^_]*?)_\b`)
+	orgStrikePattern   = regexp.MustCompile(`~~(.+?)~~`)
+)
+
+// orgEscapePattern matches lines org would read as a headline or keyword, or an escaped line
+var orgEscapePattern = regexp.MustCompile(`^(\*+\s|#\+|,)`)
+
+// OrgSink writes notes as Emacs org-mode files. Checklist items become TODO/DONE
+// headlines below their section, with due dates as deadlines and priorities as cookies.
+type OrgSink struct {
+	folderSink
+}
+
+func newOrgSink(root string, perms *vaultPermissions) Sink {
+	return &OrgSink{folderSink{root: root, ext: ".org", perms: perms}}
+}
+
+// Name identifies the sink in logs and /health
+func (s *OrgSink) Name() string {
+	return "org"
+}
+
+// WriteNote writes a note's file
+func (s *OrgSink) WriteNote(note *models.ProcessedNote) error {
+	return s.write(note, renderOrg(note))
+}
+
+// MoveNote renames a note's file after its title or category changed
+func (s *OrgSink) MoveNote(previous, note *models.ProcessedNote) error {
+	return s.move(previous, note)
+}
+
+// DeleteNote removes a note's file
+func (s *OrgSink) DeleteNote(note *models.ProcessedNote) error {
+	return s.remove(note)
+}
+
+// renderOrg renders a note as an org document with a file-level property drawer
+func renderOrg(note *models.ProcessedNote) string {
+	var sb strings.Builder
+
+	sb.WriteString(":PROPERTIES:\n")
+	sb.WriteString(fmt.Sprintf(":ID:       %s\n", note.ID))
+	sb.WriteString(fmt.Sprintf(":STATUS:   %s\n", noteStatus(note)))
+	sb.WriteString(":SOURCE:   idea-forge\n")
+	sb.WriteString(":END:\n")
+	sb.WriteString(fmt.Sprintf("#+TITLE: %s\n", note.Title))
+	sb.WriteString(fmt.Sprintf("#+DATE: [%s]\n", note.CreatedAt.Format("2006-01-02 Mon")))
+	sb.WriteString(fmt.Sprintf("#+CATEGORY: %s\n", note.Category))
+	sb.WriteString(fmt.Sprintf("#+FILETAGS: :%s:%s:\n\n", orgTag("idea-forge"), orgTag(note.Category)))
+
+	sb.WriteString(strings.TrimRight(markdownToOrg(note.Markdown, note.TaskDetails), "\n"))
+	sb.WriteString("\n")
+
+	if len(note.Links) > 0 {
+		sb.WriteString("\n* Resources\n")
+		for _, link := range note.Links {
+			sb.WriteString(fmt.Sprintf("- [[%s][%s]]", link.URL, link.Title))
+			if link.Description != "" {
+				sb.WriteString(" - " + link.Description)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString("\n* Original note\n#+BEGIN_QUOTE\n")
+	for _, line := range strings.Split(strings.TrimSpace(note.Original), "\n") {
+		sb.WriteString(orgEscape(line) + "\n")
+	}
+	sb.WriteString("#+END_QUOTE\n")
+
+	return sb.String()
+}
+
+// markdownToOrg converts note markdown to org. Markdown "##" sections become top-level
+// headlines, since the note title is the document title.
+func markdownToOrg(markdown string, details []models.TaskDetails) string {
+	var out []string
+	var nesting listNesting
+	level := 0 // level of the enclosing headline
+	fence, quote := false, false
+
+	for _, line := range strings.Split(markdown, "\n") {
+		if fence {
+			if sinkFencePattern.MatchString(line) {
+				out = append(out, "#+END_SRC")
+				fence = false
+			} else {
+				out = append(out, orgEscape(line))
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if quote && !strings.HasPrefix(trimmed, ">") {
+			out = append(out, "#+END_QUOTE")
+			quote = false
+		}
+
+		if match := sinkFencePattern.FindStringSubmatch(line); match != nil {
+			out = append(out, strings.TrimSpace("#+BEGIN_SRC "+match[2]))
+			fence = true
+			continue
+		}
+
+		if match := sinkHeadingPattern.FindStringSubmatch(line); match != nil {
+			level = max(len(match[1])-1, 1)
+			nesting.reset()
+			out = append(out, strings.Repeat("*", level)+" "+orgInline(match[2]))
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, ">") {
+			if !quote {
+				out = append(out, "#+BEGIN_QUOTE")
+				quote = true
+			}
+			out = append(out, orgEscape(orgInline(strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))))
+			continue
+		}
+
+		if sinkRulePattern.MatchString(line) {
+			out = append(out, "-----")
+			continue
+		}
+
+		if match := taskLinePattern.FindStringSubmatch(line); match != nil {
+			stars := strings.Repeat("*", level+1+nesting.level(indentWidth(leadingWhitespace(line))))
+			text := strings.TrimSpace(match[3])
+			out = append(out, orgTaskHeadline(stars, match[2] != " ", text, models.FindTaskDetails(details, text))...)
+			continue
+		}
+
+		if match := sinkListItemPattern.FindStringSubmatch(line); match != nil {
+			depth := nesting.level(indentWidth(match[1]))
+			marker := match[2]
+			if marker == "*" || marker == "+" {
+				marker = "-"
+			}
+			out = append(out, strings.Repeat("  ", depth)+marker+" "+orgInline(match[3]))
+			continue
+		}
+
+		if trimmed != "" && leadingWhitespace(line) == "" {
+			nesting.reset()
+		}
+		out = append(out, orgEscape(orgInline(line)))
+	}
+
+	if fence {
+		out = append(out, "#+END_SRC")
+	}
+	if quote {
+		out = append(out, "#+END_QUOTE")
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// orgTaskHeadline renders a checklist item as a TODO or DONE headline with its planning line
+// and effort property
+func orgTaskHeadline(stars string, done bool, text string, details *models.TaskDetails) []string {
+	keyword := "TODO"
+	if done {
+		keyword = "DONE"
+	}
+
+	headline := stars + " " + keyword
+	if details != nil && orgPriorities[details.Priority] != "" {
+		headline += " [#" + orgPriorities[details.Priority] + "]"
+	}
+	lines := []string{headline + " " + orgInline(text)}
+
+	if details == nil {
+		return lines
+	}
+	if timestamp := orgTimestamp(details.Due, details.Recurrence); timestamp != "" {
+		lines = append(lines, "DEADLINE: "+timestamp)
+	}
+	if details.Effort > 0 {
+		minutes := int(details.Effort*60 + 0.5)
+		lines = append(lines, ":PROPERTIES:", fmt.Sprintf(":EFFORT:   %d:%02d", minutes/60, minutes%60), ":END:")
+	}
+
+	return lines
+}
+
+// orgTimestamp formats a due date such as "2026-10-20" with an optional recurrence as
+// an active timestamp, e.g. "<2026-10-20 Tue +1w>". Unparseable dates give "".
+func orgTimestamp(due, recurrence string) string {
+	date, err := time.Parse("2006-01-02", due)
+	if err != nil {
+		return ""
+	}
+
+	timestamp := date.Format("2006-01-02 Mon")
+	if repeater := orgRepeater(recurrence); repeater != "" {
+		timestamp += " " + repeater
+	}
+
+	return "<" + timestamp + ">"
+}
+
+// orgRepeater converts a recurrence such as "every 2 weeks" or "weekly" to a repeater cookie such as "+2w"
+func orgRepeater(recurrence string) string {
+	recurrence = strings.ToLower(strings.TrimSpace(recurrence))
+	switch recurrence {
+	case "daily":
+		return "+1d"
+	case "weekly":
+		return "+1w"
+	case "monthly":
+		return "+1m"
+	case "yearly", "annually":
+		return "+1y"
+	}
+
+	match := orgRecurrencePattern.FindStringSubmatch(recurrence)
+	if match == nil {
+		return ""
+	}
+	count := match[1]
+	if count == "" {
+		count = "1"
+	}
+	return "+" + count + match[2][:1]
+}
+
+// orgInline converts inline markdown (code, links, emphasis) to org markup
+func orgInline(text string) string {
+	var protected []string
+	protect := func(s string) string {
+		protected = append(protected, s)
+		return fmt.Sprintf("\x00%d\x00", len(protected)-1)
+	}
+
+	text = orgCodeSpanPattern.ReplaceAllStringFunc(text, func(s string) string {
+		code := orgCodeSpanPattern.FindStringSubmatch(s)[1]
+		if strings.Contains(code, "~") {
+			return protect("=" + code + "=")
+		}
+		return protect("~" + code + "~")
+	})
+	text = orgLinkPattern.ReplaceAllStringFunc(text, func(s string) string {
+		match := orgLinkPattern.FindStringSubmatch(s)
+		return protect("[[" + match[2] + "][" + match[1] + "]]")
+	})
+	text = orgWikilinkPattern.ReplaceAllStringFunc(text, func(s string) string {
+		match := orgWikilinkPattern.FindStringSubmatch(s)
+		return protect("[[" + match[1] + "][" + match[2] + "]]")
+	})
+
+	// Bold is marked with \x01 until italics are converted, since both use "*"
+	text = orgBoldPattern.ReplaceAllString(text, "\x01$1$2\x01")
+	text = orgItalicPattern.ReplaceAllString(text, "/$1$2/")
+	text = orgStrikePattern.ReplaceAllString(text, "+$1+")
+	text = strings.ReplaceAll(text, "\x01", "*")
+
+	for i, s := range protected {
+		text = strings.Replace(text, fmt.Sprintf("\x00%d\x00", i), s, 1)
+	}
+
+	return text
+}
+
+// orgEscape keeps a line of plain text from being read as a headline or keyword
+func orgEscape(line string) string {
+	if orgEscapePattern.MatchString(line) {
+		return "," + line
+	}
+	return line
+}
+
+// orgTag converts a name to an org tag, which allows letters, digits, "_" and "@"
+func orgTag(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+func sameTitleNotes() (*models.ProcessedNote, *models.ProcessedNote) {
+	created := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	first := &models.ProcessedNote{ID: "note_aaaa1111", Title: "Backup plan", Category: "homelab", Markdown: "- [ ] Buy disks", Original: "backup plan", CreatedAt: created}
+	second := &models.ProcessedNote{ID: "note_bbbb2222", Title: "Backup plan", Category: "homelab", Markdown: "- [ ] Test restore", Original: "backup plan", CreatedAt: created}
+	return first, second
+}
+
+func TestFolderSinkKeepsSameTitleNotesApart(t *testing.T) {
+	perms, err := loadVaultPermissions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	sink := newMarkdownSink(root, perms)
+
+	first, second := sameTitleNotes()
+	for _, note := range []*models.ProcessedNote{first, second} {
+		if err := sink.WriteNote(note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(root, "homelab", "*.md"))
+	if len(files) != 2 {
+		t.Fatalf("files = %v, want one per note", files)
+	}
+
+	if err := sink.DeleteNote(first); err != nil {
+		t.Fatal(err)
+	}
+	files, _ = filepath.Glob(filepath.Join(root, "homelab", "*.md"))
+	if len(files) != 1 || !strings.HasSuffix(files[0], "-bbbb2222.md") {
+		t.Errorf("files after delete = %v, want only the second note", files)
+	}
+}
+
+func TestLogseqSinkDisambiguatesPageTitles(t *testing.T) {
+	perms, err := loadVaultPermissions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	sink := newLogseqSink(root, perms).(*LogseqSink)
+
+	first, second := sameTitleNotes()
+	for _, note := range []*models.ProcessedNote{first, second, first, second} {
+		if err := sink.WriteNote(note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	titles := map[string]bool{}
+	pages, _ := filepath.Glob(filepath.Join(root, "pages", "*.md"))
+	for _, page := range pages {
+		title, _ := logseqPageProperties(page)
+		titles[title] = true
+	}
+	if len(pages) != 2 || !titles["Backup plan"] || !titles["Backup plan (bbbb2222)"] {
+		t.Errorf("page titles = %v", titles)
+	}
+
+	journal, err := os.ReadFile(filepath.Join(root, "journals", "2026_10_18.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "- Captured [[Backup plan]] #idea-forge #[[homelab]]\n" +
+		"- Captured [[Backup plan (bbbb2222)]] #idea-forge #[[homelab]]\n"
+	if string(journal) != want {
+		t.Errorf("journal = %q, want %q", journal, want)
+	}
+
+	// Renaming the second note frees it of the suffix and updates its journal link
+	renamed := *second
+	renamed.Title = "Restore drill"
+	if err := sink.MoveNote(second, &renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.WriteNote(&renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.DeleteNote(first); err != nil {
+		t.Fatal(err)
+	}
+
+	journal, _ = os.ReadFile(filepath.Join(root, "journals", "2026_10_18.md"))
+	if want := "- Captured [[Restore drill]] #idea-forge #[[homelab]]\n"; string(journal) != want {
+		t.Errorf("journal = %q, want %q", journal, want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -59,14 +60,151 @@ func (d *Database) migrate() error {
 
 	CREATE INDEX IF NOT EXISTS idx_notes_category ON notes(category);
 	CREATE INDEX IF NOT EXISTS idx_notes_created_at ON notes(created_at DESC);
+
+	CREATE TABLE IF NOT EXISTS note_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		note_id TEXT NOT NULL,
+		revision INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		category TEXT NOT NULL,
+		markdown TEXT NOT NULL,
+		links TEXT NOT NULL DEFAULT '[]',
+		source TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		UNIQUE(note_id, revision)
+	);
+
+	CREATE TABLE IF NOT EXISTS note_conversations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		note_id TEXT NOT NULL,
+		role TEXT NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_note_conversations_note_id ON note_conversations(note_id);
+
+	CREATE TABLE IF NOT EXISTS note_embeddings (
+		note_id TEXT PRIMARY KEY,
+		provider TEXT NOT NULL,
+		dims INTEGER NOT NULL,
+		vector BLOB NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS ask_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		role TEXT NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_ask_messages_session_id ON ask_messages(session_id);
+
+	CREATE TABLE IF NOT EXISTS reviews (
+		id TEXT PRIMARY KEY,
+		period_start DATETIME NOT NULL,
+		period_end DATETIME NOT NULL,
+		markdown TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		synced_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS vault_outbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		note_id TEXT NOT NULL,
+		op TEXT NOT NULL,
+		previous TEXT,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_vault_outbox_note ON vault_outbox(note_id);
+
+	CREATE TABLE IF NOT EXISTS attachments (
+		id TEXT PRIMARY KEY,
+		note_id TEXT NOT NULL,
+		filename TEXT NOT NULL,
+		sha256 TEXT NOT NULL,
+		mime_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_attachments_note_id ON attachments(note_id);
+	CREATE INDEX IF NOT EXISTS idx_attachments_sha256 ON attachments(sha256);
+
+	-- Notes created before revision tracking get their current state as revision 1
+	INSERT INTO note_revisions (note_id, revision, title, category, markdown, links, source, created_at)
+	SELECT id, 1, title, category, markdown, links, 'api', created_at FROM notes
+	WHERE id NOT IN (SELECT note_id FROM note_revisions);
 	`
 
-	_, err := d.db.Exec(schema)
+	if _, err := d.db.Exec(schema); err != nil {
+		return err
+	}
+
+	// Columns added after the initial schema
+	columns := []struct{ name, definition string }{
+		{"related", "TEXT NOT NULL DEFAULT '[]'"},
+		{"status", "TEXT NOT NULL DEFAULT 'active'"},
+		{"updated_at", "DATETIME"},
+		{"completed_at", "DATETIME"},
+		{"task_details", "TEXT NOT NULL DEFAULT '[]'"},
+		{"vault_path", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, col := range columns {
+		if err := d.addColumn("notes", col.name, col.definition); err != nil {
+			return err
+		}
+	}
+	if err := d.addColumn("note_revisions", "task_details", "TEXT NOT NULL DEFAULT '[]'"); err != nil {
+		return err
+	}
+
+	_, err := d.db.Exec("UPDATE notes SET updated_at = created_at WHERE updated_at IS NULL")
 	return err
 }
 
-// CreateNote inserts a new note into the database
-func (d *Database) CreateNote(note *models.ProcessedNote) error {
+// addColumn adds a column to an existing table unless it is already present
+func (d *Database) addColumn(table, column, definition string) error {
+	rows, err := d.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return fmt.Errorf("failed to scan table info: %w", err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := d.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// CreateNote inserts a new note into the database and records its first revision
+func (d *Database) CreateNote(note *models.ProcessedNote, source string) error {
 	if note.ID == "" {
 		note.ID = "note_" + uuid.New().String()[:8]
 	}
@@ -76,29 +214,102 @@ func (d *Database) CreateNote(note *models.ProcessedNote) error {
 		return fmt.Errorf("failed to marshal links: %w", err)
 	}
 
-	_, err = d.db.Exec(`
-		INSERT INTO notes (id, original, title, category, markdown, links, created_at, synced_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, note.ID, note.Original, note.Title, note.Category, note.Markdown, string(linksJSON), note.CreatedAt, note.SyncedAt)
+	relatedJSON, err := marshalRelated(note.RelatedIDs)
+	if err != nil {
+		return err
+	}
+
+	tasksJSON, err := marshalTaskDetails(note.TaskDetails)
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if note.Status == "" {
+		note.Status = models.StatusActive
+	}
+	note.UpdatedAt = note.CreatedAt
+
+	_, err = tx.Exec(`
+		INSERT INTO notes (id, original, title, category, markdown, links, related, task_details, status, created_at, updated_at, completed_at, synced_at, vault_path)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, note.ID, note.Original, note.Title, note.Category, note.Markdown, string(linksJSON), relatedJSON, tasksJSON,
+		note.Status, note.CreatedAt, note.UpdatedAt, note.CompletedAt, note.SyncedAt, note.VaultPath)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert note: %w", err)
 	}
 
-	return nil
+	if err := insertRevision(tx, note, string(linksJSON), tasksJSON, source); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateNote saves the editable fields of a note, bumps its updated_at and records a new revision
+func (d *Database) UpdateNote(note *models.ProcessedNote, source string) error {
+	linksJSON, err := json.Marshal(note.Links)
+	if err != nil {
+		return fmt.Errorf("failed to marshal links: %w", err)
+	}
+
+	relatedJSON, err := marshalRelated(note.RelatedIDs)
+	if err != nil {
+		return err
+	}
+
+	tasksJSON, err := marshalTaskDetails(note.TaskDetails)
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if note.Status == "" {
+		note.Status = models.StatusActive
+	}
+	note.UpdatedAt = time.Now()
+
+	result, err := tx.Exec(`
+		UPDATE notes SET title = ?, category = ?, markdown = ?, links = ?, related = ?, task_details = ?,
+			status = ?, updated_at = ?, completed_at = ?
+		WHERE id = ?
+	`, note.Title, note.Category, note.Markdown, string(linksJSON), relatedJSON, tasksJSON,
+		note.Status, note.UpdatedAt, note.CompletedAt, note.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("note not found")
+	}
+
+	if err := insertRevision(tx, note, string(linksJSON), tasksJSON, source); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
+// noteColumns is the column list used by every query that returns full notes
+const noteColumns = "id, original, title, category, markdown, links, related, task_details, status, created_at, updated_at, completed_at, synced_at, vault_path"
+
 // GetNote retrieves a note by ID
 func (d *Database) GetNote(id string) (*models.ProcessedNote, error) {
-	var note models.ProcessedNote
-	var linksJSON string
-	var syncedAt sql.NullTime
-
-	err := d.db.QueryRow(`
-		SELECT id, original, title, category, markdown, links, created_at, synced_at
-		FROM notes WHERE id = ?
-	`, id).Scan(&note.ID, &note.Original, &note.Title, &note.Category, &note.Markdown, &linksJSON, &note.CreatedAt, &syncedAt)
+	row := d.db.QueryRow("SELECT "+noteColumns+" FROM notes WHERE id = ?", id)
 
+	note, err := scanNote(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -106,10 +317,41 @@ func (d *Database) GetNote(id string) (*models.ProcessedNote, error) {
 		return nil, fmt.Errorf("failed to get note: %w", err)
 	}
 
+	return note, nil
+}
+
+// scanNote reads a note selected with noteColumns from a query result
+func scanNote(row rowScanner) (*models.ProcessedNote, error) {
+	var note models.ProcessedNote
+	var linksJSON, relatedJSON, tasksJSON string
+	var updatedAt, completedAt, syncedAt sql.NullTime
+
+	if err := row.Scan(&note.ID, &note.Original, &note.Title, &note.Category, &note.Markdown, &linksJSON, &relatedJSON,
+		&tasksJSON, &note.Status, &note.CreatedAt, &updatedAt, &completedAt, &syncedAt, &note.VaultPath); err != nil {
+		return nil, err
+	}
+
+	note.UpdatedAt = note.CreatedAt
+	if updatedAt.Valid {
+		note.UpdatedAt = updatedAt.Time
+	}
+
+	if completedAt.Valid {
+		note.CompletedAt = &completedAt.Time
+	}
+
 	if err := json.Unmarshal([]byte(linksJSON), &note.Links); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal links: %w", err)
 	}
 
+	if err := json.Unmarshal([]byte(relatedJSON), &note.RelatedIDs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal related notes: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(tasksJSON), &note.TaskDetails); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal task details: %w", err)
+	}
+
 	if syncedAt.Valid {
 		note.SyncedAt = &syncedAt.Time
 	}
@@ -117,6 +359,30 @@ func (d *Database) GetNote(id string) (*models.ProcessedNote, error) {
 	return &note, nil
 }
 
+// marshalRelated encodes related note IDs, storing an empty array rather than null
+func marshalRelated(ids []string) (string, error) {
+	if ids == nil {
+		ids = []string{}
+	}
+	relatedJSON, err := json.Marshal(ids)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal related notes: %w", err)
+	}
+	return string(relatedJSON), nil
+}
+
+// marshalTaskDetails encodes task details, storing an empty array rather than null
+func marshalTaskDetails(details []models.TaskDetails) (string, error) {
+	if details == nil {
+		details = []models.TaskDetails{}
+	}
+	tasksJSON, err := json.Marshal(details)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal task details: %w", err)
+	}
+	return string(tasksJSON), nil
+}
+
 // ListNotes retrieves notes with optional filtering
 func (d *Database) ListNotes(category string, limit, offset int) ([]models.ProcessedNote, int, error) {
 	var args []interface{}
@@ -136,11 +402,11 @@ func (d *Database) ListNotes(category string, limit, offset int) ([]models.Proce
 
 	// Get notes
 	query := fmt.Sprintf(`
-		SELECT id, original, title, category, markdown, links, created_at, synced_at
+		SELECT %s
 		FROM notes %s
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
-	`, whereClause)
+	`, noteColumns, whereClause)
 
 	args = append(args, limit, offset)
 	rows, err := d.db.Query(query, args...)
@@ -151,31 +417,110 @@ func (d *Database) ListNotes(category string, limit, offset int) ([]models.Proce
 
 	var notes []models.ProcessedNote
 	for rows.Next() {
-		var note models.ProcessedNote
-		var linksJSON string
-		var syncedAt sql.NullTime
-
-		if err := rows.Scan(&note.ID, &note.Original, &note.Title, &note.Category, &note.Markdown, &linksJSON, &note.CreatedAt, &syncedAt); err != nil {
+		note, err := scanNote(rows)
+		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan note: %w", err)
 		}
+		notes = append(notes, *note)
+	}
 
-		if err := json.Unmarshal([]byte(linksJSON), &note.Links); err != nil {
-			return nil, 0, fmt.Errorf("failed to unmarshal links: %w", err)
+	return notes, total, nil
+}
+
+// AllNotes retrieves every note, newest first
+func (d *Database) AllNotes() ([]models.ProcessedNote, error) {
+	rows, err := d.db.Query("SELECT " + noteColumns + " FROM notes ORDER BY created_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := make([]models.ProcessedNote, 0)
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
 		}
+		notes = append(notes, *note)
+	}
+
+	return notes, rows.Err()
+}
+
+// Backlinks retrieves the notes that list a note among their related notes
+func (d *Database) Backlinks(id string) ([]models.ProcessedNote, error) {
+	rows, err := d.db.Query("SELECT "+noteColumns+" FROM notes WHERE related LIKE ? ORDER BY title", relatedPattern(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query backlinks: %w", err)
+	}
+	defer rows.Close()
 
-		if syncedAt.Valid {
-			note.SyncedAt = &syncedAt.Time
+	notes := make([]models.ProcessedNote, 0)
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		// LIKE only narrows the search; the decoded list decides
+		if slices.Contains(note.RelatedIDs, id) {
+			notes = append(notes, *note)
 		}
+	}
+
+	return notes, rows.Err()
+}
 
-		notes = append(notes, note)
+// RemoveRelated drops a note from the related notes of every other note, returning the IDs
+// of the notes that listed it. Revisions are not recorded, as related notes aren't part of them.
+func (d *Database) RemoveRelated(id string) ([]string, error) {
+	backlinks, err := d.Backlinks(id)
+	if err != nil {
+		return nil, err
 	}
 
-	return notes, total, nil
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var ids []string
+	for _, note := range backlinks {
+		relatedJSON, err := marshalRelated(slices.DeleteFunc(note.RelatedIDs, func(related string) bool {
+			return related == id
+		}))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE notes SET related = ? WHERE id = ?", relatedJSON, note.ID); err != nil {
+			return nil, fmt.Errorf("failed to update related notes: %w", err)
+		}
+		ids = append(ids, note.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit related notes: %w", err)
+	}
+
+	return ids, nil
+}
+
+// relatedPattern is a LIKE pattern for the JSON-encoded related lists that may contain an ID.
+// Wildcards in the ID only widen the match, which the caller narrows again.
+func relatedPattern(id string) string {
+	encoded, _ := json.Marshal(id)
+	return "%" + string(encoded) + "%"
 }
 
-// DeleteNote removes a note by ID
+// DeleteNote removes a note with its revision history, conversation and embedding by ID
 func (d *Database) DeleteNote(id string) error {
-	result, err := d.db.Exec("DELETE FROM notes WHERE id = ?", id)
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM notes WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete note: %w", err)
 	}
@@ -185,12 +530,24 @@ func (d *Database) DeleteNote(id string) error {
 		return fmt.Errorf("note not found")
 	}
 
-	return nil
+	if _, err := tx.Exec("DELETE FROM note_revisions WHERE note_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete revisions: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM note_conversations WHERE note_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM note_embeddings WHERE note_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete embedding: %w", err)
+	}
+
+	return tx.Commit()
 }
 
-// UpdateSyncedAt updates the synced_at timestamp for a note
-func (d *Database) UpdateSyncedAt(id string, syncedAt time.Time) error {
-	_, err := d.db.Exec("UPDATE notes SET synced_at = ? WHERE id = ?", syncedAt, id)
+// UpdateSyncedAt records when a note was last written to the vault and the file it was written to
+func (d *Database) UpdateSyncedAt(id string, syncedAt time.Time, vaultPath string) error {
+	_, err := d.db.Exec("UPDATE notes SET synced_at = ?, vault_path = ? WHERE id = ?", syncedAt, vaultPath, id)
 	return err
 }
 
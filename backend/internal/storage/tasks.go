@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// taskPriorityEmoji are the priority signifiers of the Obsidian Tasks plugin
+var taskPriorityEmoji = map[string]string{
+	"highest": "🔺",
+	"high":    "⏫",
+	"medium":  "🔼",
+	"low":     "🔽",
+	"lowest":  "⏬",
+}
+
+// taskLinePattern splits a checklist line into its marker and text
+var taskLinePattern = regexp.MustCompile(`^(\s*[-*+]\s+\[([ xX])\]\s+)(.+)$`)
+
+// taskOutput selects how checklist items and their metadata are written to the vault
+type taskOutput struct {
+	tasksPlugin bool // Obsidian Tasks emoji format
+	dataview    bool // Dataview inline fields and frontmatter
+}
+
+// parseTaskOutput reads a comma-separated list of output modes such as "tasks,dataview"
+func parseTaskOutput(value string) taskOutput {
+	var output taskOutput
+	for _, mode := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(mode)) {
+		case "":
+		case "tasks":
+			output.tasksPlugin = true
+		case "dataview":
+			output.dataview = true
+		default:
+			log.Printf("Warning: Unknown task output mode %q ignored", mode)
+		}
+	}
+	return output
+}
+
+// formatTasks annotates the checklist items of a note for the enabled output modes
+func (o taskOutput) formatTasks(note *models.ProcessedNote) string {
+	if !o.tasksPlugin && !o.dataview {
+		return note.Markdown
+	}
+
+	lines := strings.Split(note.Markdown, "\n")
+	for i, line := range lines {
+		match := taskLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		text := strings.TrimSpace(match[3])
+		details := models.FindTaskDetails(note.TaskDetails, text)
+		lines[i] = match[1] + text + o.taskSuffix(note.Category, details)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// taskSuffix builds the metadata appended to a checklist item.
+// Dataview fields come first because the Tasks plugin only reads signifiers at the end of the line.
+func (o taskOutput) taskSuffix(category string, details *models.TaskDetails) string {
+	var parts []string
+
+	if o.dataview && details != nil {
+		if !o.tasksPlugin {
+			if details.Due != "" {
+				parts = append(parts, fmt.Sprintf("[due:: %s]", details.Due))
+			}
+			if details.Priority != "" {
+				parts = append(parts, fmt.Sprintf("[priority:: %s]", details.Priority))
+			}
+			if details.Recurrence != "" {
+				parts = append(parts, fmt.Sprintf("[repeat:: %s]", details.Recurrence))
+			}
+		}
+		if details.Effort > 0 {
+			parts = append(parts, fmt.Sprintf("[effort:: %s]", formatHours(details.Effort)))
+		}
+	}
+
+	if o.tasksPlugin {
+		parts = append(parts, "#idea-forge", "#"+category)
+		if details != nil {
+			if details.Recurrence != "" {
+				parts = append(parts, "🔁 "+details.Recurrence)
+			}
+			if emoji, ok := taskPriorityEmoji[details.Priority]; ok {
+				parts = append(parts, emoji)
+			}
+			if details.Due != "" {
+				parts = append(parts, "📅 "+details.Due)
+			}
+		}
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, " ")
+}
+
+// taskSummary aggregates the checklist of a note for Dataview frontmatter
+type taskSummary struct {
+	Progress int     // percentage of completed checklist items
+	Priority string  // most urgent priority among open items
+	Effort   float64 // estimated hours of open items
+}
+
+// summarizeTasks computes progress, priority and remaining effort of a note
+func summarizeTasks(note *models.ProcessedNote) taskSummary {
+	var summary taskSummary
+
+	tasks := models.ExtractTasks(note.Markdown)
+	done := 0
+	for _, task := range tasks {
+		if task.Done {
+			done++
+			continue
+		}
+
+		details := models.FindTaskDetails(note.TaskDetails, task.Text)
+		if details == nil {
+			continue
+		}
+		summary.Effort += details.Effort
+		if rank := models.PriorityRank(details.Priority); rank != -1 &&
+			(summary.Priority == "" || rank < models.PriorityRank(summary.Priority)) {
+			summary.Priority = details.Priority
+		}
+	}
+
+	if len(tasks) > 0 {
+		summary.Progress = done * 100 / len(tasks)
+	}
+
+	return summary
+}
+
+// formatHours writes an effort estimate without trailing zeros
+func formatHours(hours float64) string {
+	return strconv.FormatFloat(hours, 'f', -1, 64)
+}
+
+// taskSuffixPattern matches the metadata formatTasks appends to a checklist item
+var taskSuffixPattern = regexp.MustCompile(`^(.*?)((?:\s\[(?:due|priority|repeat|effort):: [^\]]*\])*)` +
+	`(\s#idea-forge\s#\S+)?(\s🔁 [^🔺⏫🔼🔽⏬📅]+?)?(\s(?:🔺|⏫|🔼|🔽|⏬))?(\s📅 \d{4}-\d{2}-\d{2})?$`)
+
+// dataviewFieldPattern matches a single Dataview inline field written by formatTasks
+var dataviewFieldPattern = regexp.MustCompile(`\[(due|priority|repeat|effort):: ([^\]]*)\]`)
+
+// parseTaskText strips the metadata formatTasks appended to a checklist item,
+// returning the plain text and the details it encoded (nil if there were none)
+func parseTaskText(text string) (string, *models.TaskDetails) {
+	match := taskSuffixPattern.FindStringSubmatch(text)
+	if match == nil {
+		return text, nil
+	}
+
+	details := models.TaskDetails{Text: strings.TrimSpace(match[1])}
+	for _, field := range dataviewFieldPattern.FindAllStringSubmatch(match[2], -1) {
+		switch field[1] {
+		case "due":
+			details.Due = field[2]
+		case "priority":
+			details.Priority = field[2]
+		case "repeat":
+			details.Recurrence = field[2]
+		case "effort":
+			details.Effort, _ = strconv.ParseFloat(field[2], 64)
+		}
+	}
+	if match[4] != "" {
+		details.Recurrence = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(match[4]), "🔁"))
+	}
+	for priority, emoji := range taskPriorityEmoji {
+		if strings.TrimSpace(match[5]) == emoji {
+			details.Priority = priority
+		}
+	}
+	if match[6] != "" {
+		details.Due = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(match[6]), "📅"))
+	}
+
+	if details.Due == "" && details.Priority == "" && details.Effort == 0 && details.Recurrence == "" {
+		return details.Text, nil
+	}
+	return details.Text, &details
+}
+
+// parseTasks removes task metadata from markdown written with formatTasks and collects it
+func parseTasks(markdown string) (string, []models.TaskDetails) {
+	var details []models.TaskDetails
+
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		match := taskLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		text, taskDetails := parseTaskText(match[3])
+		lines[i] = match[1] + text
+		if taskDetails != nil {
+			details = append(details, *taskDetails)
+		}
+	}
+
+	return strings.Join(lines, "\n"), details
+}
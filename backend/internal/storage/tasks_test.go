@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+func TestTaskMetadataRoundTrip(t *testing.T) {
+	markdown := "# Plan\n\n" +
+		"- [ ] Buy disks\n" +
+		"  - [ ] Compare prices on [[Shops|the shops]]\n" +
+		"- [x] Order rack\n" +
+		"* [ ] Test restore\n" +
+		"\t+ [X] Read the #restic docs\n" +
+		"Text that mentions - [ ] a checklist"
+
+	buy := models.TaskDetails{Text: "Buy disks", Due: "2026-10-20", Priority: "high", Effort: 1.5, Recurrence: "every week"}
+	compare := models.TaskDetails{Text: "Compare prices on [[Shops|the shops]]", Priority: "lowest"}
+	restore := models.TaskDetails{Text: "Test restore", Effort: 2}
+	note := &models.ProcessedNote{
+		Category:    "homelab",
+		Markdown:    markdown,
+		TaskDetails: []models.TaskDetails{buy, compare, restore},
+	}
+
+	tests := []struct {
+		name   string
+		output taskOutput
+		want   []models.TaskDetails
+	}{
+		{"plain", taskOutput{}, nil},
+		// The Tasks plugin has no effort signifier
+		{"tasks", taskOutput{tasksPlugin: true}, []models.TaskDetails{
+			{Text: buy.Text, Due: buy.Due, Priority: buy.Priority, Recurrence: buy.Recurrence},
+			compare,
+		}},
+		{"dataview", taskOutput{dataview: true}, []models.TaskDetails{buy, compare, restore}},
+		{"tasks and dataview", taskOutput{tasksPlugin: true, dataview: true}, []models.TaskDetails{buy, compare, restore}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted := tt.output.formatTasks(note)
+			if tt.output != (taskOutput{}) && formatted == markdown {
+				t.Fatalf("formatTasks added no metadata:\n%s", formatted)
+			}
+
+			parsed, details := parseTasks(formatted)
+			if parsed != markdown {
+				t.Errorf("parseTasks markdown =\n%s\nwant\n%s\nformatted as\n%s", parsed, markdown, formatted)
+			}
+			if !reflect.DeepEqual(details, tt.want) {
+				t.Errorf("parseTasks details = %+v, want %+v\nformatted as\n%s", details, tt.want, formatted)
+			}
+		})
+	}
+}
+
+func TestParseTaskText(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantText string
+		want     *models.TaskDetails
+	}{
+		{"Buy disks", "Buy disks", nil},
+		{"Tag with #homelab only", "Tag with #homelab only", nil},
+		{"Read [the docs](https://example.com)", "Read [the docs](https://example.com)", nil},
+		{"Buy disks #idea-forge #homelab", "Buy disks", nil},
+		{"Buy disks #idea-forge #homelab 📅 2026-10-20", "Buy disks", &models.TaskDetails{Text: "Buy disks", Due: "2026-10-20"}},
+		{"Buy disks #idea-forge #homelab 🔁 every month ⏬", "Buy disks",
+			&models.TaskDetails{Text: "Buy disks", Priority: "lowest", Recurrence: "every month"}},
+		{"Buy disks [due:: 2026-10-20] [priority:: medium] [repeat:: every day] [effort:: 0.25]", "Buy disks",
+			&models.TaskDetails{Text: "Buy disks", Due: "2026-10-20", Priority: "medium", Recurrence: "every day", Effort: 0.25}},
+		{"Buy disks [effort:: 3] #idea-forge #homelab 🔺 📅 2026-10-20", "Buy disks",
+			&models.TaskDetails{Text: "Buy disks", Due: "2026-10-20", Priority: "highest", Effort: 3}},
+	}
+
+	for _, tt := range tests {
+		text, details := parseTaskText(tt.text)
+		if text != tt.wantText || !reflect.DeepEqual(details, tt.want) {
+			t.Errorf("parseTaskText(%q) = %q, %+v, want %q, %+v", tt.text, text, details, tt.wantText, tt.want)
+		}
+	}
+}
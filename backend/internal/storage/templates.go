@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// defaultTemplateName is the template used for notes without a category-specific override
+const defaultTemplateName = "note.md.tmpl"
+
+//go:embed templates/note.md.tmpl
+var defaultTemplates embed.FS
+
+// noteTemplateData is the data available to note templates: every ProcessedNote field plus derived values
+type noteTemplateData struct {
+	models.ProcessedNote
+	Slug        string        // slugified title
+	Filename    string        // vault filename without the .md extension
+	Tasks       []models.Task // checklist items parsed from the markdown
+	Dataview    bool          // whether Dataview frontmatter fields are enabled
+	Project     string        // project name for Dataview queries
+	Progress    int           // percentage of completed checklist items
+	Priority    string        // most urgent priority among open checklist items
+	Effort      float64       // estimated hours of open checklist items
+	Related     []noteLink    // related notes and backlinks, sorted by title
+	Attachments []string      // vault filenames of the attachments, oldest first
+}
+
+// noteLink is a wikilink target for note templates
+type noteLink struct {
+	Filename string // vault filename without the .md extension
+	Title    string
+}
+
+// templateFuncs are the helpers available to note templates
+var templateFuncs = template.FuncMap{
+	"slug":    slugify,
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"title":   titleCase,
+	"trim":    strings.TrimSpace,
+	"join":    strings.Join,
+	"replace": strings.ReplaceAll,
+	"now":     time.Now,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"rfc3339": func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	},
+	"moment": func(format string, t time.Time) string {
+		return formatMoment(t, format)
+	},
+	"hours":  formatHours,
+	"yaml":   yamlScalar,
+	"inline": markdownInline,
+	"alias":  wikilinkAlias,
+}
+
+// noteTemplates resolves the template for each category
+type noteTemplates struct {
+	fallback   *noteTemplate
+	categories map[string]*noteTemplate
+}
+
+// noteTemplate is a parsed note template and the frontmatter keys it owns
+type noteTemplate struct {
+	*template.Template
+	keys []string // top-level frontmatter keys, including those only written under a condition
+}
+
+// templateKeyPattern matches a top-level key in the frontmatter of a template
+var templateKeyPattern = regexp.MustCompile(`(?m)^([A-Za-z0-9_][\w-]*):`)
+
+// parseNoteTemplate parses a note template and collects the keys of its frontmatter
+func parseNoteTemplate(name, text string) (*noteTemplate, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &noteTemplate{Template: tmpl}
+	if front, _, ok := splitFrontmatter(text); ok {
+		for _, match := range templateKeyPattern.FindAllStringSubmatch(front, -1) {
+			parsed.keys = append(parsed.keys, match[1])
+		}
+	}
+	return parsed, nil
+}
+
+// loadNoteTemplates parses the embedded default template and any overrides in dir.
+// In dir, note.md.tmpl replaces the default and <category>.md.tmpl applies to a single category.
+func loadNoteTemplates(dir string) (*noteTemplates, error) {
+	data, err := defaultTemplates.ReadFile("templates/" + defaultTemplateName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read default template: %w", err)
+	}
+	fallback, err := parseNoteTemplate(defaultTemplateName, string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse default template: %w", err)
+	}
+
+	templates := &noteTemplates{
+		fallback:   fallback,
+		categories: make(map[string]*noteTemplate),
+	}
+
+	if dir == "" {
+		return templates, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.md.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+		}
+
+		name := filepath.Base(path)
+		tmpl, err := parseNoteTemplate(name, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+		}
+
+		if name == defaultTemplateName {
+			templates.fallback = tmpl
+		} else {
+			templates.categories[strings.TrimSuffix(name, ".md.tmpl")] = tmpl
+		}
+	}
+
+	return templates, nil
+}
+
+// forCategory returns the template to render notes of a category with
+func (t *noteTemplates) forCategory(category string) *noteTemplate {
+	if tmpl, ok := t.categories[category]; ok {
+		return tmpl
+	}
+	return t.fallback
+}
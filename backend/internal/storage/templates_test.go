@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNoteTemplateKeys(t *testing.T) {
+	templates, err := loadNoteTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := templates.forCategory("coding").keys
+	for _, key := range []string{"id", "status", "progress", "priority", "effort", "project"} {
+		if !slices.Contains(keys, key) {
+			t.Errorf("default template keys %v lack %q", keys, key)
+		}
+	}
+}
+
+func TestPreserveFrontmatterDropsConditionalKeys(t *testing.T) {
+	templates, err := loadNoteTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := "---\nid: note_1\npriority: high\neffort: 2\nprogress: 50\nreviewed: true\n---\n\n# Old\n"
+	rendered := "---\nid: note_1\n---\n\n# New\n"
+
+	merged, err := preserveFrontmatter(rendered, existing, templates.fallback.keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	front, body, _ := splitFrontmatter(merged)
+	for _, stale := range []string{"priority", "effort", "progress"} {
+		if strings.Contains(front, stale+":") {
+			t.Errorf("stale key %q kept:\n%s", stale, front)
+		}
+	}
+	if !strings.Contains(front, "reviewed: true") {
+		t.Errorf("hand-added key lost:\n%s", front)
+	}
+	if body != "\n# New\n" {
+		t.Errorf("body = %q", body)
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations spells out letters that do not decompose into ASCII
+var transliterations = map[rune]string{
+	// Latin
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l", 'þ': "th", 'ı': "i",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i",
+	'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g",
+}
+
+// stripMarks returns a transformer removing accents left over after canonical decomposition,
+// e.g. "é" becomes "e". A chain keeps internal buffers, so each call needs its own.
+func stripMarks() transform.Transformer {
+	return transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+}
+
+// transliterate approximates text in ASCII. Letters without a known spelling, such as CJK
+// characters and emoji, are kept and left for slugify to drop.
+func transliterate(text string) string {
+	stripped, _, err := transform.String(stripMarks(), strings.ToLower(text))
+	if err != nil {
+		stripped = strings.ToLower(text)
+	}
+
+	var sb strings.Builder
+	for _, r := range stripped {
+		if spelled, ok := transliterations[r]; ok {
+			sb.WriteString(spelled)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
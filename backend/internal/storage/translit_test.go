@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Set up K3s":   "set-up-k3s",
+		"Café Crème":   "cafe-creme",
+		"Straße & Ærø": "strasse-aero",
+		"Домашняя лаборатория": "domashnyaya-laboratoriya",
+	}
+	for title, want := range tests {
+		if got := slugify(title); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+// Run with -race: every note, sink and attachment write slugifies concurrently
+func TestSlugifyConcurrent(t *testing.T) {
+	titles := []string{"Café Crème", "Ærø Überlegung", "Crème brûlée", "Ελληνικά"}
+	want := make([]string, len(titles))
+	for i, title := range titles {
+		want[i] = slugify(title)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 200; n++ {
+				i := (g + n) % len(titles)
+				if got := slugify(titles[i]); got != want[i] {
+					t.Errorf("slugify(%q) = %q, want %q", titles[i], got, want[i])
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// quarantineFolder holds orphaned note files moved aside by a resync
+const quarantineFolder = "_orphaned"
+
+// VaultFile is a note file in the IdeaForge folder of the vault, identified by its frontmatter id
+type VaultFile struct {
+	Path    string    `json:"path"` // relative to the vault root
+	ID      string    `json:"id"`
+	ModTime time.Time `json:"modified"`
+}
+
+// ScanNotes lists the note files in the IdeaForge folder that carry a frontmatter id.
+// Index notes, reviews, quarantined files and Syncthing conflict copies have no id or are skipped.
+func (w *ObsidianWriter) ScanNotes() ([]VaultFile, error) {
+	root := filepath.Join(w.vaultPath, w.folderName)
+
+	var files []VaultFile
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		name := entry.Name()
+		if entry.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == quarantineFolder) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") || filepath.Ext(name) != ".md" || conflictPattern.MatchString(name) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		front, _, ok := splitFrontmatter(string(data))
+		if !ok {
+			return nil
+		}
+		fields, err := parseFrontmatter(front)
+		if err != nil {
+			return nil
+		}
+
+		id := frontmatterString(fields, "id")
+		if id == "" {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(w.vaultPath, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, VaultFile{Path: filepath.ToSlash(rel), ID: id, ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan vault: %w", err)
+	}
+
+	return files, nil
+}
+
+// NotePath returns where a note is written, relative to the vault root
+func (w *ObsidianWriter) NotePath(note *models.ProcessedNote) string {
+	rel, err := w.resolvePath(note)
+	if err != nil {
+		return w.currentPath(note)
+	}
+	return rel
+}
+
+// MoveFile moves a file within the vault, creating the destination folder as needed
+func (w *ObsidianWriter) MoveFile(from, to string) error {
+	fromPath, err := w.resolve(from)
+	if err != nil {
+		return err
+	}
+	toPath, err := w.resolve(to)
+	if err != nil {
+		return err
+	}
+
+	if fromPath == toPath {
+		return nil
+	}
+
+	if err := w.mkdirAll(filepath.Dir(toPath)); err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	if err := os.Rename(fromPath, toPath); err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+	w.recordChange(changeMove, toPath, fromPath, nil)
+
+	return nil
+}
+
+// QuarantineFile moves a file into the quarantine folder, keeping its path below the
+// IdeaForge folder, and returns its new location relative to the vault root
+func (w *ObsidianWriter) QuarantineFile(path string) (string, error) {
+	rel, err := filepath.Rel(w.folderName, filepath.FromSlash(path))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(path)
+	}
+
+	target := filepath.ToSlash(filepath.Join(w.folderName, quarantineFolder, rel))
+	if err := w.MoveFile(path, target); err != nil {
+		return "", err
+	}
+
+	return target, nil
+}
+
+// resolve turns a vault-relative path into an absolute one inside the vault
+func (w *ObsidianWriter) resolve(path string) (string, error) {
+	full := filepath.Join(w.vaultPath, filepath.FromSlash(path))
+
+	// Prevent path traversal
+	if !strings.HasPrefix(filepath.Clean(full), filepath.Clean(w.vaultPath)) {
+		return "", fmt.Errorf("invalid file path: attempted path traversal")
+	}
+
+	return full, nil
+}
+
+var (
+	// footerPattern matches the footer written below every note
+	footerPattern = regexp.MustCompile(`(?s)\n+---\n\*Original note: "(.*)"\*\n\*Generated by \[\[` + mocName + `\]\] on [^\n]*\*\s*$`)
+	// relatedSectionPattern matches the Related section written below the resources
+	relatedSectionPattern = regexp.MustCompile(`\n## Related\n(?:\s*- \[\[[^\]\n]*\]\])*\s*$`)
+	// attachmentsSectionPattern matches the Attachments section written below the related notes
+	attachmentsSectionPattern = regexp.MustCompile(`\n## Attachments\n(?:\s*!\[\[[^\]\n]*\]\])*\s*$`)
+	// resourceLinkPattern matches an entry of the Resources section
+	resourceLinkPattern = regexp.MustCompile(`^- \[(.*)\]\((\S+)\)(?: - (.*))?$`)
+	// markdownEscapePattern matches characters escaped by markdownInline
+	markdownEscapePattern = regexp.MustCompile(`\\([\\*_\x60\[\]<>#|])`)
+)
+
+// ReadNote parses a note file back into a note, reversing what WriteNote renders.
+// Fields missing from the file fall back to values derived from its path.
+func (w *ObsidianWriter) ReadNote(path string) (*models.ProcessedNote, error) {
+	note, _, err := w.readNote(path)
+	return note, err
+}
+
+// readNote parses a note file and reports whether it has the footer with the original note
+func (w *ObsidianWriter) readNote(path string) (*models.ProcessedNote, bool, error) {
+	full, err := w.resolve(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return parseNote(full, string(data), info.ModTime())
+}
+
+// ParseNote parses note content as if it were the file at path, relative to the vault root
+func (w *ObsidianWriter) ParseNote(path, content string) (*models.ProcessedNote, error) {
+	full, err := w.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	note, _, err := parseNote(full, content, time.Now())
+	return note, err
+}
+
+// parseNote parses the content of the note file at full; modTime is used when it has no created
+// date. It reports whether the file has the footer with the original note; without one the
+// title stands in for the original.
+func parseNote(full, content string, modTime time.Time) (*models.ProcessedNote, bool, error) {
+	front, body, _ := splitFrontmatter(content)
+	fields, err := parseFrontmatter(front)
+	if err != nil {
+		return nil, false, err
+	}
+
+	note := &models.ProcessedNote{
+		ID:        frontmatterString(fields, "id"),
+		Category:  frontmatterString(fields, "category"),
+		Status:    frontmatterString(fields, "status"),
+		Links:     []models.Link{},
+		CreatedAt: modTime,
+	}
+
+	if note.Category == "" {
+		note.Category = filepath.Base(filepath.Dir(full))
+	}
+	if !models.IsValidStatus(note.Status) {
+		note.Status = models.StatusActive
+	}
+	if created, err := time.Parse(time.RFC3339, frontmatterString(fields, "created")); err == nil {
+		note.CreatedAt = created
+	}
+
+	// Footer with the original note
+	match := footerPattern.FindStringSubmatchIndex(body)
+	if match != nil {
+		note.Original = markdownEscapePattern.ReplaceAllString(body[match[2]:match[3]], "$1")
+		body = body[:match[0]]
+	}
+
+	// Attachments section, which is rebuilt from the database on every write
+	if loc := attachmentsSectionPattern.FindStringIndex(body); loc != nil {
+		body = body[:loc[0]]
+	}
+
+	// Related section, which is rebuilt from the database on every write
+	if loc := relatedSectionPattern.FindStringIndex(body); loc != nil {
+		body = body[:loc[0]]
+	}
+
+	// Resources section with search links
+	if i := strings.LastIndex(body, "\n## Resources\n"); i != -1 {
+		for _, line := range strings.Split(body[i:], "\n") {
+			if link := resourceLinkPattern.FindStringSubmatch(strings.TrimSpace(line)); link != nil {
+				note.Links = append(note.Links, models.Link{Title: link[1], URL: link[2], Description: link[3]})
+			}
+		}
+		body = body[:i]
+	}
+
+	note.Markdown, note.TaskDetails = parseTasks(strings.TrimSpace(body))
+
+	if aliases, ok := frontmatterValue(fields, "aliases").([]any); ok && len(aliases) > 0 {
+		note.Title = fmt.Sprint(aliases[0])
+	}
+	if note.Title == "" {
+		note.Title = firstHeading(note.Markdown)
+	}
+	if note.Title == "" {
+		note.Title = strings.TrimSuffix(filepath.Base(full), ".md")
+	}
+	if note.Original == "" {
+		note.Original = note.Title
+	}
+
+	return note, match != nil, nil
+}
+
+// firstHeading returns the text of the first level-one heading in markdown
+func firstHeading(markdown string) string {
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		}
+	}
+	return ""
+}
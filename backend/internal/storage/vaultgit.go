@@ -0,0 +1,340 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/mail"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGitWindow = 10 * time.Second
+	defaultGitAuthor = "IdeaForge <ideaforge@localhost>"
+)
+
+// Vault changes recorded for git commits
+const (
+	changeCreate = "create"
+	changeUpdate = "update"
+	changeMove   = "move"
+	changeDelete = "delete"
+)
+
+// vaultChange is one file written, moved or deleted by the writer
+type vaultChange struct {
+	action string
+	path   string // relative to the vault root
+	from   string // previous path of a move
+	noteID string
+	folder string // IdeaForge folder of the writer, trimmed from paths in commit messages
+}
+
+// vaultGit commits changes to a vault kept in a git repository. Changes are collected
+// for a short window and committed together, so a note write and the index updates it
+// triggers end up in one commit. Only the paths the writers touched are staged; other
+// changes in the working tree are left alone. The writers of every folder of a vault
+// share one vaultGit.
+type vaultGit struct {
+	dir    string // vault root, where git runs
+	window time.Duration
+	env    []string
+
+	mu      sync.Mutex
+	pending []vaultChange
+	timer   *time.Timer
+
+	commitMu sync.Mutex // serializes git invocations
+}
+
+// loadVaultGit reads OBSIDIAN_GIT_COMMIT, OBSIDIAN_GIT_WINDOW and OBSIDIAN_GIT_AUTHOR.
+// It returns nil when git commits are disabled.
+func loadVaultGit(vaultPath string) (*vaultGit, error) {
+	if os.Getenv("OBSIDIAN_GIT_COMMIT") != "true" {
+		return nil, nil
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git binary not found: %w", err)
+	}
+
+	window := defaultGitWindow
+	if value := os.Getenv("OBSIDIAN_GIT_WINDOW"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("OBSIDIAN_GIT_WINDOW must be a duration such as 10s, got %q", value)
+		}
+		window = parsed
+	}
+
+	author := os.Getenv("OBSIDIAN_GIT_AUTHOR")
+	if author == "" {
+		author = defaultGitAuthor
+	}
+	address, err := mail.ParseAddress(author)
+	if err != nil {
+		return nil, fmt.Errorf("OBSIDIAN_GIT_AUTHOR must look like \"Name <email>\", got %q", author)
+	}
+
+	g := &vaultGit{
+		dir:    vaultPath,
+		window: window,
+		env: []string{
+			"GIT_AUTHOR_NAME=" + address.Name,
+			"GIT_AUTHOR_EMAIL=" + address.Address,
+			"GIT_COMMITTER_NAME=" + address.Name,
+			"GIT_COMMITTER_EMAIL=" + address.Address,
+		},
+	}
+
+	if out, err := g.git("rev-parse", "--is-inside-work-tree"); err != nil || strings.TrimSpace(out) != "true" {
+		return nil, fmt.Errorf("vault is not inside a git work tree: %s", vaultPath)
+	}
+
+	return g, nil
+}
+
+// record adds a change to the pending batch, folding it into an earlier change of the
+// same file, and starts the batch window if it isn't running
+func (g *vaultGit) record(change vaultChange) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.pending = mergeChange(g.pending, change)
+
+	if g.timer == nil && len(g.pending) > 0 {
+		g.timer = time.AfterFunc(g.window, g.flush)
+	}
+}
+
+// mergeChange appends a change to a batch. A file created or moved and then updated is
+// still reported as created or moved, and a file created and deleted again is dropped.
+func mergeChange(pending []vaultChange, change vaultChange) []vaultChange {
+	for i := len(pending) - 1; i >= 0; i-- {
+		existing := &pending[i]
+		if existing.path != change.path {
+			continue
+		}
+		if existing.noteID == "" {
+			existing.noteID = change.noteID
+		}
+
+		switch {
+		case change.action == changeUpdate && existing.action != changeDelete:
+			return pending
+		case change.action == changeCreate && existing.action == changeDelete:
+			existing.action = changeUpdate
+			return pending
+		case change.action == changeDelete && existing.action == changeCreate:
+			return append(pending[:i], pending[i+1:]...)
+		case change.action == changeDelete && existing.action == changeMove:
+			existing.action, existing.path, existing.from = changeDelete, existing.from, ""
+			return pending
+		}
+		return append(pending, change)
+	}
+
+	return append(pending, change)
+}
+
+// flush commits the pending batch
+func (g *vaultGit) flush() {
+	g.mu.Lock()
+	changes := g.pending
+	g.pending = nil
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+	g.mu.Unlock()
+
+	if len(changes) == 0 {
+		return
+	}
+
+	g.commitMu.Lock()
+	defer g.commitMu.Unlock()
+
+	if err := g.commit(changes); err != nil {
+		log.Printf("Warning: Failed to commit vault changes: %v", err)
+	}
+}
+
+// commit stages the files touched by a batch and commits just those paths
+func (g *vaultGit) commit(changes []vaultChange) error {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		for _, path := range []string{change.from, change.path} {
+			if path != "" && !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	// A path git has never seen that no longer exists can't be staged
+	out, err := g.git(append([]string{"ls-files", "-z", "--"}, paths...)...)
+	if err != nil {
+		return err
+	}
+	tracked := make(map[string]bool)
+	for _, path := range strings.Split(out, "\x00") {
+		tracked[path] = true
+	}
+
+	var stage []string
+	for _, path := range paths {
+		if _, err := os.Lstat(filepath.Join(g.dir, filepath.FromSlash(path))); err == nil || tracked[path] {
+			stage = append(stage, path)
+		}
+	}
+	if len(stage) == 0 {
+		return nil
+	}
+
+	if _, err := g.git(append([]string{"add", "-A", "--"}, stage...)...); err != nil {
+		return err
+	}
+
+	// Nothing to commit when the files were rewritten with the same content
+	if _, err := g.git(append([]string{"diff", "--cached", "--quiet", "--"}, stage...)...); err == nil {
+		return nil
+	}
+
+	args := append([]string{"commit", "--quiet", "--no-verify", "-m", g.message(changes), "--"}, stage...)
+	if _, err := g.git(args...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// message describes a batch. A batch with a single note change is named after it,
+// e.g. "ideaforge: create homelab/2026-10-17-set-up-k3s.md (note_ab12cd34)", with
+// every change listed in the body when index files changed along with it.
+func (g *vaultGit) message(changes []vaultChange) string {
+	if len(changes) == 1 {
+		return "ideaforge: " + changes[0].describe()
+	}
+
+	var noteChanges []vaultChange
+	for _, change := range changes {
+		if change.noteID != "" {
+			noteChanges = append(noteChanges, change)
+		}
+	}
+
+	var sb strings.Builder
+	if len(noteChanges) == 1 {
+		sb.WriteString("ideaforge: " + noteChanges[0].describe())
+	} else {
+		sb.WriteString(fmt.Sprintf("ideaforge: update %d files", len(changes)))
+	}
+	sb.WriteString("\n\n")
+	for _, change := range changes {
+		sb.WriteString("- " + change.describe() + "\n")
+	}
+
+	return sb.String()
+}
+
+// describe renders a change, with paths relative to its IdeaForge folder
+func (change vaultChange) describe() string {
+	text := change.action + " " + change.display(change.path)
+	if change.action == changeMove {
+		text = change.action + " " + change.display(change.from) + " -> " + change.display(change.path)
+	}
+	if change.noteID != "" {
+		text += " (" + change.noteID + ")"
+	}
+	return text
+}
+
+// display trims the IdeaForge folder of the change from a vault-relative path
+func (change vaultChange) display(path string) string {
+	return strings.TrimPrefix(path, change.folder+"/")
+}
+
+// git runs a git command in the vault and returns its output
+func (g *vaultGit) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"--literal-pathspecs"}, args...)...)
+	cmd.Dir = g.dir
+	cmd.Env = append(os.Environ(), g.env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return stdout.String(), nil
+}
+
+// recordChange queues a vault change for the next git commit. full and from are
+// absolute paths; the note id is read from the file's frontmatter when content is nil.
+func (w *ObsidianWriter) recordChange(action, full, from string, content []byte) {
+	if w.git == nil {
+		return
+	}
+
+	rel, err := filepath.Rel(w.vaultPath, full)
+	if err != nil {
+		return
+	}
+	change := vaultChange{action: action, path: filepath.ToSlash(rel), folder: filepath.ToSlash(w.folderName)}
+
+	if from != "" {
+		if fromRel, err := filepath.Rel(w.vaultPath, from); err == nil {
+			change.from = filepath.ToSlash(fromRel)
+		}
+	}
+
+	if content == nil {
+		content, _ = os.ReadFile(full)
+	}
+	change.noteID = contentNoteID(content)
+
+	w.git.record(change)
+}
+
+// existsAction returns changeUpdate for a file that exists and changeCreate otherwise
+func existsAction(full string) string {
+	if _, err := os.Lstat(full); errors.Is(err, fs.ErrNotExist) {
+		return changeCreate
+	}
+	return changeUpdate
+}
+
+// contentNoteID returns the frontmatter id of a note file's content, or ""
+func contentNoteID(content []byte) string {
+	front, _, ok := splitFrontmatter(string(content))
+	if !ok {
+		return ""
+	}
+	fields, err := parseFrontmatter(front)
+	if err != nil {
+		return ""
+	}
+	return frontmatterString(fields, "id")
+}
+
+// FlushCommits commits pending vault changes without waiting for the batch window.
+// Short-lived commands call it before exiting.
+func (w *ObsidianWriter) FlushCommits() {
+	if w.git != nil {
+		w.git.flush()
+	}
+}
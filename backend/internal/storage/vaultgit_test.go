@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeChange(t *testing.T) {
+	create := vaultChange{action: changeCreate, path: "IdeaForge/a.md", noteID: "note_a"}
+	update := vaultChange{action: changeUpdate, path: "IdeaForge/a.md"}
+	remove := vaultChange{action: changeDelete, path: "IdeaForge/a.md"}
+	move := vaultChange{action: changeMove, path: "IdeaForge/b.md", from: "IdeaForge/a.md", noteID: "note_a"}
+	index := vaultChange{action: changeUpdate, path: "IdeaForge/IdeaForge.md"}
+
+	tests := []struct {
+		name    string
+		changes []vaultChange
+		want    []vaultChange
+	}{
+		{"create then update stays a create", []vaultChange{create, update}, []vaultChange{create}},
+		{"create then delete is dropped", []vaultChange{create, index, remove}, []vaultChange{index}},
+		{"delete then create is an update", []vaultChange{remove, create},
+			[]vaultChange{{action: changeUpdate, path: "IdeaForge/a.md", noteID: "note_a"}}},
+		{"move then update stays a move", []vaultChange{move, {action: changeUpdate, path: "IdeaForge/b.md"}}, []vaultChange{move}},
+		{"move then delete deletes the original", []vaultChange{move, {action: changeDelete, path: "IdeaForge/b.md"}},
+			[]vaultChange{{action: changeDelete, path: "IdeaForge/a.md", noteID: "note_a"}}},
+		{"update learns the note id", []vaultChange{update, create},
+			[]vaultChange{{action: changeUpdate, path: "IdeaForge/a.md", noteID: "note_a"}, create}},
+	}
+
+	for _, tt := range tests {
+		var pending []vaultChange
+		for _, change := range tt.changes {
+			pending = mergeChange(pending, change)
+		}
+		if !reflect.DeepEqual(pending, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, pending, tt.want)
+		}
+	}
+}
+
+func TestVaultGitMessage(t *testing.T) {
+	g := &vaultGit{}
+
+	single := g.message([]vaultChange{{folder: "IdeaForge", action: changeCreate, path: "IdeaForge/homelab/set-up-k3s.md", noteID: "note_ab12cd34"}})
+	if want := "ideaforge: create homelab/set-up-k3s.md (note_ab12cd34)"; single != want {
+		t.Errorf("message = %q, want %q", single, want)
+	}
+
+	withIndex := g.message([]vaultChange{
+		{folder: "IdeaForge", action: changeMove, path: "IdeaForge/coding/b.md", from: "IdeaForge/coding/a.md", noteID: "note_1"},
+		{folder: "IdeaForge", action: changeUpdate, path: "IdeaForge/coding/_index.md"},
+	})
+	want := "ideaforge: move coding/a.md -> coding/b.md (note_1)\n\n" +
+		"- move coding/a.md -> coding/b.md (note_1)\n" +
+		"- update coding/_index.md\n"
+	if withIndex != want {
+		t.Errorf("message = %q, want %q", withIndex, want)
+	}
+
+	batch := g.message([]vaultChange{
+		{folder: "IdeaForge", action: changeCreate, path: "IdeaForge/a.md", noteID: "note_1"},
+		{folder: "IdeaForge", action: changeDelete, path: "IdeaForge/b.md", noteID: "note_2"},
+	})
+	if !strings.HasPrefix(batch, "ideaforge: update 2 files\n\n") {
+		t.Errorf("message = %q, want a summary subject", batch)
+	}
+
+	// Folders of one vault share a batch, each change trimmed by its own folder
+	folders := g.message([]vaultChange{
+		{folder: "IdeaForge", action: changeCreate, path: "IdeaForge/a.md", noteID: "note_1"},
+		{folder: "Projects/Inbox", action: changeCreate, path: "Projects/Inbox/b.md", noteID: "note_2"},
+	})
+	want = "ideaforge: update 2 files\n\n" +
+		"- create a.md (note_1)\n" +
+		"- create b.md (note_2)\n"
+	if folders != want {
+		t.Errorf("message = %q, want %q", folders, want)
+	}
+}
+
+func TestVaultGitCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	write := func(rel, content string) {
+		t.Helper()
+		full := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q")
+	t.Setenv("OBSIDIAN_GIT_COMMIT", "true")
+	t.Setenv("OBSIDIAN_GIT_WINDOW", "1h")
+	t.Setenv("OBSIDIAN_GIT_AUTHOR", "IdeaForge Test <test@localhost>")
+
+	g, err := loadVaultGit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A file edited by hand is not IdeaForge's to commit
+	write("Personal/diary.md", "dear diary\n")
+
+	write("IdeaForge/coding/a.md", "---\nid: note_1\n---\n")
+	g.record(vaultChange{folder: "IdeaForge", action: changeCreate, path: "IdeaForge/coding/a.md", noteID: "note_1"})
+	write("IdeaForge/IdeaForge.md", "index\n")
+	g.record(vaultChange{folder: "IdeaForge", action: changeCreate, path: "IdeaForge/IdeaForge.md"})
+	// Created and deleted within the window: never reaches git
+	g.record(vaultChange{folder: "IdeaForge", action: changeCreate, path: "IdeaForge/tmp.md"})
+	g.record(vaultChange{folder: "IdeaForge", action: changeDelete, path: "IdeaForge/tmp.md"})
+	g.flush()
+
+	if got := run("log", "--format=%an|%s"); got != "IdeaForge Test|ideaforge: create coding/a.md (note_1)\n" {
+		t.Errorf("log = %q", got)
+	}
+	if got := run("status", "--porcelain"); got != "?? Personal/\n" {
+		t.Errorf("status = %q, want only the hand-edited file left", got)
+	}
+
+	// Moves and deletes stage both sides
+	if err := os.Rename(filepath.Join(dir, "IdeaForge/coding/a.md"), filepath.Join(dir, "IdeaForge/coding/b.md")); err != nil {
+		t.Fatal(err)
+	}
+	g.record(vaultChange{folder: "IdeaForge", action: changeMove, path: "IdeaForge/coding/b.md", from: "IdeaForge/coding/a.md", noteID: "note_1"})
+	g.flush()
+
+	if got := run("log", "-1", "--format=%s"); got != "ideaforge: move coding/a.md -> coding/b.md (note_1)\n" {
+		t.Errorf("log = %q", got)
+	}
+	if got := run("ls-files", "IdeaForge"); got != "IdeaForge/IdeaForge.md\nIdeaForge/coding/b.md\n" {
+		t.Errorf("tracked = %q", got)
+	}
+
+	// Rewriting a file with the same content makes no empty commit
+	g.record(vaultChange{folder: "IdeaForge", action: changeUpdate, path: "IdeaForge/IdeaForge.md"})
+	g.flush()
+	if got := strings.Count(run("log", "--format=%h"), "\n"); got != 2 {
+		t.Errorf("%d commits, want 2", got)
+	}
+
+	// Nothing is committed before the window ends unless flushed
+	g.record(vaultChange{folder: "IdeaForge", action: changeDelete, path: "IdeaForge/coding/b.md", noteID: "note_1"})
+	os.Remove(filepath.Join(dir, "IdeaForge/coding/b.md"))
+	time.Sleep(10 * time.Millisecond)
+	if got := strings.Count(run("log", "--format=%h"), "\n"); got != 2 {
+		t.Errorf("%d commits before the window ended, want 2", got)
+	}
+	g.flush()
+	if got := run("log", "-1", "--format=%s"); got != "ideaforge: delete coding/b.md (note_1)\n" {
+		t.Errorf("log = %q", got)
+	}
+}
+
+func TestVaultsShareGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	t.Setenv("OBSIDIAN_VAULT_PATH", dir)
+	t.Setenv("OBSIDIAN_FOLDER", "")
+	t.Setenv("OBSIDIAN_VAULTS", "")
+	t.Setenv("OBSIDIAN_ROUTES", "coding=default:Projects/Inbox")
+	t.Setenv("OBSIDIAN_GIT_COMMIT", "true")
+	t.Setenv("OBSIDIAN_GIT_WINDOW", "1h")
+
+	vaults, err := NewVaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writers := vaults.All()
+	if len(writers) != 2 {
+		t.Fatalf("got %d writers, want 2", len(writers))
+	}
+	if writers[0].git == nil || writers[1].git != writers[0].git {
+		t.Fatal("the folders of one vault don't share a git batcher")
+	}
+
+	full := filepath.Join(dir, "Projects", "Inbox", "set-up-k3s.md")
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("---\nid: note_1\n---\n")
+	if err := os.WriteFile(full, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	writers[1].recordChange(changeCreate, full, "", content)
+	vaults.FlushCommits()
+
+	out, err := exec.Command("git", "-C", dir, "log", "--format=%s").CombinedOutput()
+	if err != nil {
+		t.Fatalf("git log: %v\n%s", err, out)
+	}
+	if got := string(out); got != "ideaforge: create set-up-k3s.md (note_1)\n" {
+		t.Errorf("log = %q", got)
+	}
+}
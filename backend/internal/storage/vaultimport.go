@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// skippedVaultFolders are never searched for notes to import
+var skippedVaultFolders = map[string]bool{
+	".obsidian": true,
+	".trash":    true,
+	".git":      true,
+}
+
+// ScanImport lists the markdown files below a vault folder that could be imported as notes,
+// relative to the vault root. The IdeaForge folder is skipped, since resync handles the
+// files in it, and so are hidden files and Syncthing conflict copies.
+func (w *ObsidianWriter) ScanImport(folder string) ([]string, error) {
+	root, err := w.resolve(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("folder not found: %s", folder)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a folder: %s", folder)
+	}
+
+	managedRoot := filepath.Join(w.vaultPath, w.folderName)
+
+	var paths []string
+	err = filepath.WalkDir(root, func(full string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := entry.Name()
+		if entry.IsDir() {
+			if full == managedRoot || (full != root && (strings.HasPrefix(name, ".") || skippedVaultFolders[name])) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") || filepath.Ext(name) != ".md" || conflictPattern.MatchString(name) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(w.vaultPath, full)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", folder, err)
+	}
+
+	return paths, nil
+}
+
+// ReadImport parses a markdown file written outside IdeaForge into a note. Checklists and
+// frontmatter are read as for note files. The category is left empty unless the frontmatter
+// names a known one, as its category or as a tag, and the body doubles as the original note.
+func (w *ObsidianWriter) ReadImport(rel string) (*models.ProcessedNote, error) {
+	note, footer, err := w.readNote(rel)
+	if err != nil {
+		return nil, err
+	}
+
+	if !models.IsValidCategory(note.Category) {
+		note.Category = ""
+
+		data, err := w.ReadFile(rel)
+		if err != nil {
+			return nil, err
+		}
+		if front, _, ok := splitFrontmatter(data); ok {
+			fields, err := parseFrontmatter(front)
+			if err != nil {
+				return nil, err
+			}
+			for _, tag := range frontmatterList(fields, "tags") {
+				if tag = strings.ToLower(strings.TrimPrefix(tag, "#")); models.IsValidCategory(tag) {
+					note.Category = tag
+					break
+				}
+			}
+		}
+	}
+
+	if !footer {
+		note.Original = note.Markdown
+	}
+
+	return note, nil
+}
+
+// frontmatterList returns a frontmatter value that may be a YAML list or a comma-separated string
+func frontmatterList(fields yaml.MapSlice, key string) []string {
+	var values []string
+	switch value := frontmatterValue(fields, key).(type) {
+	case nil:
+	case []any:
+		for _, item := range value {
+			values = append(values, fmt.Sprint(item))
+		}
+	default:
+		for _, item := range strings.Split(fmt.Sprint(value), ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+// AdoptFile marks a vault file as the file of a note by adding its id to the frontmatter,
+// leaving the rest of the file as it is. Files without frontmatter get a block with just the id.
+func (w *ObsidianWriter) AdoptFile(rel, id string) error {
+	full, err := w.resolve(rel)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	content := string(data)
+
+	line, err := yamlScalar(id)
+	if err != nil {
+		return err
+	}
+	line = "id: " + line + "\n"
+
+	front, _, ok := splitFrontmatter(content)
+	switch {
+	case !ok:
+		content = frontmatterDelimiter + "\n" + line + frontmatterDelimiter + "\n\n" + content
+	default:
+		fields, err := parseFrontmatter(front)
+		if err != nil {
+			return err
+		}
+		switch frontmatterString(fields, "id") {
+		case id:
+			return nil
+		case "":
+			// Insert right after the opening delimiter, which may be followed by \r\n
+			open := strings.Index(content, "\n") + 1
+			content = content[:open] + line + content[open:]
+		default:
+			return fmt.Errorf("file already has a different id in its frontmatter")
+		}
+	}
+
+	if err := w.writeFile(full, []byte(content)); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// managed reports whether a vault-relative path lies in the IdeaForge folder
+func (w *ObsidianWriter) managed(rel string) bool {
+	folder := filepath.ToSlash(w.folderName)
+	return rel == folder || strings.HasPrefix(rel, folder+"/")
+}
+
+// AdoptedFile returns the file of a note that was imported where it lay, outside the
+// IdeaForge folder, if it still carries the note's id. ScanNotes does not see these files.
+func (w *ObsidianWriter) AdoptedFile(note *models.ProcessedNote) *VaultFile {
+	if note.VaultPath == "" || w.managed(note.VaultPath) {
+		return nil
+	}
+
+	owner, exists := w.fileOwner(note.VaultPath)
+	if !exists || owner != note.ID {
+		return nil
+	}
+
+	file := VaultFile{Path: path.Clean(note.VaultPath), ID: note.ID}
+	if full, err := w.resolve(note.VaultPath); err == nil {
+		if info, err := os.Stat(full); err == nil {
+			file.ModTime = info.ModTime()
+		}
+	}
+
+	return &file
+}
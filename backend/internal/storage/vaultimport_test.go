@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadImportOriginal(t *testing.T) {
+	vault := t.TempDir()
+	w, err := openObsidianWriter(defaultVaultName, vault, "IdeaForge", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"Notes/garden.md":   "# Garden\n\nPlant tomatoes in May.\n",
+		"Notes/imported.md": "# Rack\n\nMount the rack.\n\n---\n*Original note: \"rack in the garage\"*\n*Generated by [[IdeaForge]] on 2026-10-18*\n",
+	}
+	for rel, content := range files {
+		full := filepath.Join(vault, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path, title, original string
+	}{
+		// A hand-written note is its own original, not just its title
+		{"Notes/garden.md", "Garden", "# Garden\n\nPlant tomatoes in May."},
+		{"Notes/imported.md", "Rack", "rack in the garage"},
+	}
+	for _, tt := range tests {
+		note, err := w.ReadImport(tt.path)
+		if err != nil {
+			t.Fatalf("ReadImport(%s): %v", tt.path, err)
+		}
+		if note.Title != tt.title || note.Original != tt.original {
+			t.Errorf("ReadImport(%s) title, original = %q, %q, want %q, %q", tt.path, note.Title, note.Original, tt.title, tt.original)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kilo40/idea-forge/internal/models"
+)
+
+// defaultVaultName names the vault at OBSIDIAN_VAULT_PATH in OBSIDIAN_ROUTES and /health
+const defaultVaultName = "default"
+
+// Vaults routes each note to a folder of an Obsidian vault by its tags and category, as set
+// in OBSIDIAN_ROUTES. Notes without a route go to the IdeaForge folder of the default vault.
+type Vaults struct {
+	writers    []*ObsidianWriter // the default vault first, then one per routed vault folder
+	categories map[string]*ObsidianWriter
+	tags       []tagRoute // checked in order, before the category
+}
+
+// tagRoute sends the notes using an inline tag to a vault folder
+type tagRoute struct {
+	tag    string
+	writer *ObsidianWriter
+}
+
+// NewVaults opens the default vault and the vaults named in OBSIDIAN_VAULTS, such as
+// "private=/vaults/private,team=/vaults/team", and validates OBSIDIAN_ROUTES against them.
+// Routes map a category or "#tag" to "vault" or "vault:folder", e.g.
+// "personal=private,coding=team:Projects/Inbox,#homelab-rebuild=team". Any invalid route or
+// missing vault is an error, rather than sending notes to a vault they weren't meant for.
+func NewVaults() (*Vaults, error) {
+	primary, err := NewObsidianWriter()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Vaults{
+		writers:    []*ObsidianWriter{primary},
+		categories: make(map[string]*ObsidianWriter),
+	}
+
+	paths := map[string]string{defaultVaultName: primary.vaultPath}
+	for _, entry := range configList(os.Getenv("OBSIDIAN_VAULTS")) {
+		name, vaultPath, ok := strings.Cut(entry, "=")
+		name, vaultPath = strings.TrimSpace(name), strings.TrimSpace(vaultPath)
+		switch {
+		case !ok || name == "" || vaultPath == "":
+			return nil, fmt.Errorf("OBSIDIAN_VAULTS entries must look like name=/path/to/vault, got %q", entry)
+		case strings.ContainsAny(name, ":#"):
+			return nil, fmt.Errorf("vault name %q must not contain \":\" or \"#\"", name)
+		case paths[name] != "":
+			return nil, fmt.Errorf("vault %q is defined twice", name)
+		}
+
+		info, err := os.Stat(vaultPath)
+		if err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("vault %s does not exist: %s", name, vaultPath)
+		}
+		for other, otherPath := range paths {
+			if filepath.Clean(otherPath) == filepath.Clean(vaultPath) {
+				return nil, fmt.Errorf("vaults %s and %s share the path %s", other, name, vaultPath)
+			}
+		}
+		paths[name] = vaultPath
+	}
+
+	for _, entry := range configList(os.Getenv("OBSIDIAN_ROUTES")) {
+		key, target, ok := strings.Cut(entry, "=")
+		key, target = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(target)
+		if !ok || key == "" || target == "" {
+			return nil, fmt.Errorf("OBSIDIAN_ROUTES entries must look like category=vault[:folder], got %q", entry)
+		}
+
+		name, folder, _ := strings.Cut(target, ":")
+		vaultPath, ok := paths[name]
+		if !ok {
+			return nil, fmt.Errorf("route %s names unknown vault %q", key, name)
+		}
+		if folder == "" {
+			folder = defaultFolderName()
+		}
+		folder = path.Clean(strings.Trim(filepath.ToSlash(folder), "/"))
+		if folder == "." || folder == ".." || strings.HasPrefix(folder, "../") {
+			return nil, fmt.Errorf("route %s has an invalid folder %q", key, target)
+		}
+
+		writer, err := v.writer(name, vaultPath, folder)
+		if err != nil {
+			return nil, err
+		}
+
+		if tag, ok := strings.CutPrefix(key, "#"); ok {
+			if tag == "" || slices.ContainsFunc(v.tags, func(route tagRoute) bool { return route.tag == tag }) {
+				return nil, fmt.Errorf("tag route %q is empty or defined twice", key)
+			}
+			v.tags = append(v.tags, tagRoute{tag: tag, writer: writer})
+			continue
+		}
+
+		if !models.IsValidCategory(key) {
+			return nil, fmt.Errorf("route %s is not a category; tags are written as #%s", key, key)
+		}
+		if v.categories[key] != nil {
+			return nil, fmt.Errorf("category %s is routed twice", key)
+		}
+		v.categories[key] = writer
+	}
+
+	return v, nil
+}
+
+// writer returns the writer of a vault folder, opening it on first use. Folders of one vault
+// may not contain each other, since each writer treats every note file below its folder as its own.
+func (v *Vaults) writer(vault, vaultPath, folder string) (*ObsidianWriter, error) {
+	var shared *ObsidianWriter
+	for _, w := range v.writers {
+		if filepath.Clean(w.vaultPath) != filepath.Clean(vaultPath) {
+			continue
+		}
+		existing := path.Clean(filepath.ToSlash(w.folderName))
+		if existing == folder {
+			return w, nil
+		}
+		if strings.HasPrefix(folder, existing+"/") || strings.HasPrefix(existing, folder+"/") {
+			return nil, fmt.Errorf("folders %s and %s of vault %s overlap", existing, folder, vault)
+		}
+		shared = w
+	}
+
+	name := vault
+	if folder != path.Clean(filepath.ToSlash(defaultFolderName())) {
+		name += ":" + folder
+	}
+
+	w, err := openObsidianWriter(name, vaultPath, folder, shared)
+	if err != nil {
+		return nil, fmt.Errorf("vault %s: %w", name, err)
+	}
+
+	v.writers = append(v.writers, w)
+	return w, nil
+}
+
+// configList splits a comma-separated setting, dropping empty entries
+func configList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// For returns the writer of the vault folder a note belongs in: the first tag route matching
+// one of its inline tags, else its category's route, else the default vault
+func (v *Vaults) For(note *models.ProcessedNote) *ObsidianWriter {
+	if len(v.tags) > 0 {
+		tags := noteHashtags(note.Markdown)
+		for _, route := range v.tags {
+			if slices.Contains(tags, route.tag) {
+				return route.writer
+			}
+		}
+	}
+
+	if w := v.categories[note.Category]; w != nil {
+		return w
+	}
+	return v.writers[0]
+}
+
+// Default returns the writer of the default vault, which also receives the weekly reviews
+func (v *Vaults) Default() *ObsidianWriter {
+	return v.writers[0]
+}
+
+// All returns the writer of every vault folder, the default vault first
+func (v *Vaults) All() []*ObsidianWriter {
+	return v.writers
+}
+
+// Named returns the writer of a vault folder by the name shown in /health, or nil.
+// An empty name is the default vault.
+func (v *Vaults) Named(name string) *ObsidianWriter {
+	if name == "" {
+		return v.writers[0]
+	}
+	for _, w := range v.writers {
+		if w.name == name {
+			return w
+		}
+	}
+	return nil
+}
+
+// Partition groups notes by the vault folder they are routed to
+func (v *Vaults) Partition(notes []models.ProcessedNote) map[*ObsidianWriter][]models.ProcessedNote {
+	routed := make(map[*ObsidianWriter][]models.ProcessedNote, len(v.writers))
+	for _, note := range notes {
+		w := v.For(&note)
+		routed[w] = append(routed[w], note)
+	}
+	return routed
+}
+
+// SetRelated sets how related notes are looked up, for every vault folder
+func (v *Vaults) SetRelated(fn RelatedFunc) {
+	for _, w := range v.writers {
+		w.SetRelated(fn)
+	}
+}
+
+// SetAttachments sets where attachments are stored and how they are looked up, for every vault folder
+func (v *Vaults) SetAttachments(store *AttachmentStore, fn AttachmentsFunc) {
+	for _, w := range v.writers {
+		w.SetAttachments(store, fn)
+	}
+}
+
+// FlushCommits commits the pending vault changes of every vault
+func (v *Vaults) FlushCommits() {
+	for _, w := range v.writers {
+		w.FlushCommits()
+	}
+}
+
+// Health reports the self-check and ownership of every vault folder
+func (v *Vaults) Health() []models.VaultHealth {
+	health := make([]models.VaultHealth, 0, len(v.writers))
+	for _, w := range v.writers {
+		health = append(health, w.Health())
+	}
+	return health
+}
+
+// VaultName is the name of the vault folder a writer handles, as used in /health
+func (w *ObsidianWriter) VaultName() string {
+	return w.name
+}
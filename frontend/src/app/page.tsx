@@ -35,8 +35,8 @@ export default function Home() {
     setError(null);
 
     try {
-      const note = await api.createNote(content);
-      setNotes((prev) => [note, ...prev]);
+      const response = await api.createNote(content);
+      setNotes((prev) => [...response.notes, ...prev]);
     } catch (err) {
       setError(err instanceof Error ? err.message : "Failed to create note");
     } finally {
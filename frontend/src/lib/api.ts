@@ -8,6 +8,14 @@ interface Link {
   description?: string;
 }
 
+interface TaskDetails {
+  text: string;
+  due?: string;
+  priority?: "highest" | "high" | "medium" | "low" | "lowest";
+  effort?: number;
+  recurrence?: string;
+}
+
 export interface ProcessedNote {
   id: string;
   original: string;
@@ -15,8 +23,14 @@ export interface ProcessedNote {
   category: string;
   markdown: string;
   links: Link[];
+  related_ids?: string[];
+  task_details?: TaskDetails[];
+  status?: "active" | "completed" | "archived";
   created_at: string;
+  updated_at?: string;
+  completed_at?: string;
   synced_at?: string;
+  vault_path?: string;
 }
 
 interface NotesResponse {
@@ -24,6 +38,11 @@ interface NotesResponse {
   total: number;
 }
 
+interface CreateNoteResponse {
+  notes: ProcessedNote[];
+  total: number;
+}
+
 interface CategoryCount {
   name: string;
   count: number;
@@ -61,10 +80,10 @@ class ApiClient {
     return response.json();
   }
 
-  async createNote(content: string): Promise<ProcessedNote> {
-    return this.request<ProcessedNote>("/api/notes", {
+  async createNote(content: string, split = true): Promise<CreateNoteResponse> {
+    return this.request<CreateNoteResponse>("/api/notes", {
       method: "POST",
-      body: JSON.stringify({ content }),
+      body: JSON.stringify({ content, split }),
     });
   }
 